@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/MicahParks/ipcoin"
+	"github.com/MicahParks/ipcoin/ctxkey"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+const usage = "usage: address_stats verify | backfill"
+
+// address_stats recomputes the address_stats table from the comment and transfer tables. verify reports drift, including
+// balance checkpoints that do not match the transfers they cover, and exits with status 1 if there is any. backfill
+// repairs both kinds of drift it reports.
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l := slog.Default()
+	ctx = context.WithValue(ctx, ctxkey.Logger, l.With("defaultLogger", true))
+
+	if len(os.Args) != 2 || (os.Args[1] != "verify" && os.Args[1] != "backfill") {
+		l.ErrorContext(ctx, usage)
+		os.Exit(2)
+	}
+
+	c, err := config()
+	if err != nil {
+		l.ErrorContext(ctx, "Failed to read config.",
+			ipcoin.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	l.InfoContext(ctx, "Connecting to PostgreSQL.")
+	pool, err := storage.NewPool(ctx, c.DBDSN)
+	if err != nil {
+		l.ErrorContext(ctx, "Failed to connect to PostgreSQL.",
+			ipcoin.LogErr, err,
+		)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	l.InfoContext(ctx, "Connected to PostgreSQL.")
+
+	var drifts []storage.AddressStatsDrift
+	var checkpointDrifts []storage.BalanceCheckpointDrift
+	switch os.Args[1] {
+	case "verify":
+		drifts, err = storage.VerifyAddressStats(ctx, pool)
+		if err != nil {
+			l.ErrorContext(ctx, "Failed to verify address stats.",
+				ipcoin.LogErr, err,
+			)
+			os.Exit(1)
+		}
+		checkpointDrifts, err = storage.VerifyBalanceCheckpoints(ctx, pool)
+		if err != nil {
+			l.ErrorContext(ctx, "Failed to verify balance checkpoints.",
+				ipcoin.LogErr, err,
+			)
+			os.Exit(1)
+		}
+	case "backfill":
+		drifts, checkpointDrifts, err = backfill(ctx, pool)
+		if err != nil {
+			l.ErrorContext(ctx, "Failed to backfill address stats.",
+				ipcoin.LogErr, err,
+			)
+			os.Exit(1)
+		}
+	}
+
+	for _, d := range drifts {
+		l.WarnContext(ctx, "Address stats drift.",
+			"address", d.Expected.Address,
+			"balanceDiffExpected", d.Expected.BalanceDiff,
+			"balanceDiffActual", d.Actual.BalanceDiff,
+			"commentCountExpected", d.Expected.CommentCount,
+			"commentCountActual", d.Actual.CommentCount,
+			"transferCountExpected", d.Expected.TransferCount,
+			"transferCountActual", d.Actual.TransferCount,
+		)
+	}
+	for _, d := range checkpointDrifts {
+		l.WarnContext(ctx, "Balance checkpoint drift.",
+			"address", d.Address,
+			"asOf", d.AsOf,
+			"balanceDiffExpected", d.Expected,
+			"balanceDiffActual", d.Actual,
+		)
+	}
+	switch {
+	case os.Args[1] == "backfill":
+		l.InfoContext(ctx, "Backfilled address stats and balance checkpoints.",
+			"repaired", len(drifts),
+			"repairedCheckpoints", len(checkpointDrifts),
+		)
+	case len(drifts) > 0:
+		l.ErrorContext(ctx, "Address stats have drifted. Run backfill to repair them.",
+			"drifted", len(drifts),
+		)
+		os.Exit(1)
+	case len(checkpointDrifts) > 0:
+		l.ErrorContext(ctx, "Balance checkpoints do not match the transfers they cover. Run backfill to repair them.",
+			"drifted", len(checkpointDrifts),
+		)
+		os.Exit(1)
+	default:
+		l.InfoContext(ctx, "Address stats and balance checkpoints match comments and transfers.")
+	}
+}
+
+func backfill(ctx context.Context, pool *pgxpool.Pool) ([]storage.AddressStatsDrift, []storage.BalanceCheckpointDrift, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	drifts, err := storage.BackfillAddressStats(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	checkpointDrifts, err := storage.RepairBalanceCheckpoints(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return drifts, checkpointDrifts, nil
+}
+
+func config() (ipcoin.Config, error) {
+	b, err := os.ReadFile("config.json")
+	if err != nil {
+		return ipcoin.Config{}, fmt.Errorf("failed to read config JSON file: %w", err)
+	}
+	var c ipcoin.Config
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return ipcoin.Config{}, fmt.Errorf("failed to unmarshal config JSON: %w", err)
+	}
+	if c.DBDSN == "" {
+		return ipcoin.Config{}, errors.New("config.json must contain a database DSN")
+	}
+	return c, nil
+}
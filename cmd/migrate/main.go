@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/MicahParks/ipcoin"
+	"github.com/MicahParks/ipcoin/ctxkey"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+const usage = "usage: migrate up | down [steps] | status"
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l := slog.Default()
+	ctx = context.WithValue(ctx, ctxkey.Logger, l.With("defaultLogger", true))
+
+	if len(os.Args) < 2 {
+		l.ErrorContext(ctx, usage)
+		os.Exit(2)
+	}
+
+	c, err := config()
+	if err != nil {
+		l.ErrorContext(ctx, "Failed to read config.",
+			ipcoin.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	l.InfoContext(ctx, "Connecting to PostgreSQL.")
+	pool, err := storage.NewPool(ctx, c.DBDSN)
+	if err != nil {
+		l.ErrorContext(ctx, "Failed to connect to PostgreSQL.",
+			ipcoin.LogErr, err,
+		)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	l.InfoContext(ctx, "Connected to PostgreSQL.")
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := storage.MigrateUp(ctx, pool)
+		if err != nil {
+			l.ErrorContext(ctx, "Failed to apply migrations.",
+				ipcoin.LogErr, err,
+			)
+			os.Exit(1)
+		}
+		for _, m := range applied {
+			l.InfoContext(ctx, "Applied migration.",
+				"version", m.Version,
+				"name", m.Name,
+			)
+		}
+		l.InfoContext(ctx, "Database is up to date.",
+			"applied", len(applied),
+		)
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				l.ErrorContext(ctx, "Steps must be a positive integer.",
+					"steps", os.Args[2],
+				)
+				os.Exit(2)
+			}
+		}
+		reverted, err := storage.MigrateDown(ctx, pool, steps)
+		if err != nil {
+			l.ErrorContext(ctx, "Failed to revert migrations.",
+				ipcoin.LogErr, err,
+			)
+			os.Exit(1)
+		}
+		for _, m := range reverted {
+			l.InfoContext(ctx, "Reverted migration.",
+				"version", m.Version,
+				"name", m.Name,
+			)
+		}
+	case "status":
+		status, err := storage.GetMigrationStatus(ctx, pool)
+		if err != nil {
+			l.ErrorContext(ctx, "Failed to read migration status.",
+				ipcoin.LogErr, err,
+			)
+			os.Exit(1)
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.Applied != nil {
+				applied = s.Applied.String()
+			}
+			l.InfoContext(ctx, "Migration status.",
+				"version", s.Migration.Version,
+				"name", s.Migration.Name,
+				"applied", applied,
+			)
+		}
+		err = storage.CheckSchemaVersion(ctx, pool)
+		if err != nil {
+			l.WarnContext(ctx, "Database schema does not match this build.",
+				ipcoin.LogErr, err,
+			)
+		}
+	default:
+		l.ErrorContext(ctx, usage)
+		os.Exit(2)
+	}
+}
+
+func config() (ipcoin.Config, error) {
+	b, err := os.ReadFile("config.json")
+	if err != nil {
+		return ipcoin.Config{}, fmt.Errorf("failed to read config JSON file: %w", err)
+	}
+	var c ipcoin.Config
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return ipcoin.Config{}, fmt.Errorf("failed to unmarshal config JSON: %w", err)
+	}
+	if c.DBDSN == "" {
+		return ipcoin.Config{}, errors.New("config.json must contain a database DSN")
+	}
+	return c, nil
+}
@@ -48,11 +48,35 @@ func main() {
 	defer pool.Close()
 	l.InfoContext(ctx, "Connected to PostgreSQL.")
 
+	err = storage.CheckSchemaVersion(ctx, pool)
+	if err != nil {
+		l.ErrorContext(ctx, "Database schema does not match this build. Run cmd/migrate.",
+			ipcoin.LogErr, err,
+		)
+		return
+	}
+
+	moderator, err := server.NewConfigModerator(c)
+	if err != nil {
+		l.ErrorContext(ctx, "Failed to create moderator.",
+			ipcoin.LogErr, err,
+		)
+		return
+	}
+	if moderator == nil {
+		l.WarnContext(ctx, "No moderator configured. Comments and memos will not be moderated.")
+	}
+
 	runtimeKey := uuid.New().String()
-	s := server.New(ctx, c, server.NewRealClock(), l, server.NewLeaderboardMemCache(ctx, pool), pool, runtimeKey)
+	clock := server.NewRealClock()
+	s := server.New(ctx, c, clock, l, server.NewLeaderboardMemCache(ctx, c, clock, pool), moderator, pool, runtimeKey)
 
 	gs := grpc.NewServer()
 	proto.RegisterIPCoinServiceServer(gs, s)
+	if c.AdminToken != "" {
+		proto.RegisterIPCoinAdminServiceServer(gs, server.NewAdmin(c, server.NewRealClock(), l, pool))
+		l.InfoContext(ctx, "Registered admin gRPC service.")
+	}
 	lis, err := net.Listen("tcp", ":8080")
 	if err != nil {
 		l.ErrorContext(ctx, "Failed to create listener.",
@@ -91,6 +115,22 @@ func main() {
 		return
 	}
 
+	conn, err := grpc.NewClient(":8080", opts...)
+	if err != nil {
+		l.ErrorContext(ctx, "Failed to create gRPC client.",
+			ipcoin.LogErr, err,
+		)
+		return
+	}
+	defer conn.Close()
+	err = mux.HandlePath(http.MethodGet, statementDownloadPath, statementDownload(mux, proto.NewIPCoinServiceClient(conn), l))
+	if err != nil {
+		l.ErrorContext(ctx, "Failed to register statement download.",
+			ipcoin.LogErr, err,
+		)
+		return
+	}
+
 	l.InfoContext(ctx, "Serving HTTP proxy on port 8081.")
 	err = http.ListenAndServe(":8081", allCORS(mux))
 	if err != nil {
@@ -132,5 +172,11 @@ func config() (ipcoin.Config, error) {
 	if c.DBDSN == "" {
 		return ipcoin.Config{}, errors.New("config.json must contain a database DSN")
 	}
+	if c.LeaderboardPrefixIPv4 < 0 || c.LeaderboardPrefixIPv4 > 32 {
+		return ipcoin.Config{}, fmt.Errorf("config.json leaderboardPrefixIPv4 must be 0 to 32 but is %d", c.LeaderboardPrefixIPv4)
+	}
+	if c.LeaderboardPrefixIPv6 < 0 || c.LeaderboardPrefixIPv6 > 128 {
+		return ipcoin.Config{}, fmt.Errorf("config.json leaderboardPrefixIPv6 must be 0 to 128 but is %d", c.LeaderboardPrefixIPv6)
+	}
 	return c, nil
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/status"
+
+	"github.com/MicahParks/ipcoin"
+	"github.com/MicahParks/ipcoin/proto"
+)
+
+const (
+	statementDownloadPath = "/api/v1/statement/download"
+	// statementDownloadTimeout is the deadline of the GetStatement call so a stalled download cannot stream forever.
+	statementDownloadTimeout = 10 * time.Minute
+)
+
+type statementRow struct {
+	Created      time.Time `json:"created"`
+	TransferID   string    `json:"transferId"`
+	Direction    string    `json:"direction"`
+	Counterparty string    `json:"counterparty"`
+	Amount       int64     `json:"amount"`
+	Balance      int64     `json:"balance"`
+	Memo         string    `json:"memo"`
+	MemoCensored bool      `json:"memoCensored"`
+}
+
+// statementDownload streams the GetStatement RPC as a CSV or NDJSON file. The optional address query parameter
+// defaults to the caller and the format query parameter is either csv, the default, or ndjson.
+func statementDownload(mux *runtime.ServeMux, client proto.IPCoinServiceClient, l *slog.Logger) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "ndjson" {
+			http.Error(w, "format must be csv or ndjson", http.StatusBadRequest)
+			return
+		}
+		request := &proto.GetStatementRequest{}
+		a := r.URL.Query().Get("address")
+		if a != "" {
+			address, err := netip.ParseAddr(a)
+			if err != nil {
+				http.Error(w, "invalid address", http.StatusBadRequest)
+				return
+			}
+			request.Address = address.AsSlice()
+		}
+
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, proto.IPCoinService_GetStatement_FullMethodName)
+		if err != nil {
+			http.Error(w, "invalid request metadata", http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(ctx, statementDownloadTimeout)
+		defer cancel()
+		stream, err := client.GetStatement(ctx, request)
+		if err != nil {
+			s := status.Convert(err)
+			http.Error(w, s.Message(), runtime.HTTPStatusFromCode(s.Code()))
+			return
+		}
+		// Read the first entry before writing headers so errors such as rate limiting get a proper status code.
+		response, err := stream.Recv()
+		if err != nil && !errors.Is(err, io.EOF) {
+			s := status.Convert(err)
+			http.Error(w, s.Message(), runtime.HTTPStatusFromCode(s.Code()))
+			return
+		}
+
+		var write func(row statementRow) error
+		var flush func() error
+		switch format {
+		case "ndjson":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			enc := json.NewEncoder(w)
+			write = func(row statementRow) error {
+				return enc.Encode(row)
+			}
+			flush = func() error {
+				return nil
+			}
+		default:
+			w.Header().Set("Content-Type", "text/csv")
+			csvW := csv.NewWriter(w)
+			_ = csvW.Write([]string{"created", "transfer_id", "direction", "counterparty", "amount", "balance", "memo", "memo_censored"})
+			write = func(row statementRow) error {
+				return csvW.Write([]string{
+					row.Created.Format(time.RFC3339Nano),
+					row.TransferID,
+					row.Direction,
+					row.Counterparty,
+					strconv.FormatInt(row.Amount, 10),
+					strconv.FormatInt(row.Balance, 10),
+					row.Memo,
+					strconv.FormatBool(row.MemoCensored),
+				})
+			}
+			flush = func() error {
+				csvW.Flush()
+				return csvW.Error()
+			}
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="ipcoin-statement.%s"`, format))
+
+		for err == nil {
+			entry := response.GetEntry()
+			counterparty, _ := netip.AddrFromSlice(entry.GetCounterpartyAddress())
+			direction := "received"
+			if entry.GetDirection() == proto.StatementDirection_STATEMENT_DIRECTION_SENT {
+				direction = "sent"
+			}
+			row := statementRow{
+				Created:      entry.GetCreated().AsTime(),
+				TransferID:   entry.GetTransferId(),
+				Direction:    direction,
+				Counterparty: counterparty.String(),
+				Amount:       entry.GetAmount(),
+				Balance:      entry.GetBalance(),
+				Memo:         entry.GetMemo(),
+				MemoCensored: entry.GetMemoCensored(),
+			}
+			err = write(row)
+			if err != nil {
+				break
+			}
+			response, err = stream.Recv()
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			l.ErrorContext(ctx, "Failed to stream statement download.",
+				ipcoin.LogErr, err,
+			)
+		}
+		err = flush()
+		if err != nil {
+			l.ErrorContext(ctx, "Failed to flush statement download.",
+				ipcoin.LogErr, err,
+			)
+		}
+	}
+}
@@ -1,7 +1,17 @@
 package ipcoin
 
 type Config struct {
+	// AdminToken authenticates IPCoinAdminService calls. The admin service is not served when it is empty.
+	AdminToken         string `json:"adminToken"`
 	CloudflareRequired bool   `json:"cloudflareRequired"`
 	DBDSN              string `json:"dbDSN"`
-	OpenAIAPIKey       string `json:"openaiAPIKey"`
+	// LeaderboardPrefixIPv4 and LeaderboardPrefixIPv6 are the prefix lengths the prefix leaderboards group addresses
+	// by. Zero uses /24 and /48.
+	LeaderboardPrefixIPv4 int    `json:"leaderboardPrefixIPv4"`
+	LeaderboardPrefixIPv6 int    `json:"leaderboardPrefixIPv6"`
+	ModerationRulesPath   string `json:"moderationRulesPath"`
+	OpenAIAPIKey          string `json:"openaiAPIKey"`
+	// RateLimitPostgres keeps rate limit budgets in PostgreSQL so every replica shares them and they survive restarts.
+	// They are kept in memory by default.
+	RateLimitPostgres bool `json:"rateLimitPostgres"`
 }
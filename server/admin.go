@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/MicahParks/ipcoin"
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+const (
+	maxReviewerLength     = 100
+	maxReviewerNoteLength = 1_000
+)
+
+type adminServer struct {
+	c     ipcoin.Config
+	clock Clock
+	l     *slog.Logger
+	pool  *pgxpool.Pool
+	proto.UnimplementedIPCoinAdminServiceServer
+}
+
+// NewAdmin creates the IPCoinAdminService. Every call is rejected unless it carries the configured admin token, so an
+// empty token disables the service.
+func NewAdmin(c ipcoin.Config, clock Clock, l *slog.Logger, pool *pgxpool.Pool) proto.IPCoinAdminServiceServer {
+	return &adminServer{
+		c:     c,
+		clock: clock,
+		l:     l,
+		pool:  pool,
+	}
+}
+
+func (a *adminServer) ListFlaggedComments(ctx context.Context, request *proto.ListFlaggedCommentsRequest) (*proto.ListFlaggedCommentsResponse, error) {
+	err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storageRequest := storage.ReadCommentFlaggedRequest{}
+	if request.GetPageToken() != "" {
+		token, err := decodeFeedPageToken(request.GetPageToken())
+		if err != nil || token.Comment == nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		storageRequest.After = token.Comment
+	}
+	tx, err := beginTx(ctx, a.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	storageResponse, err := storage.ReadCommentFlagged(ctx, tx, storageRequest)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to read flagged comments")
+	}
+	flagged := make([]*proto.FlaggedComment, len(storageResponse.Flagged))
+	for i, f := range storageResponse.Flagged {
+		flagged[i] = &proto.FlaggedComment{
+			Comment:    protoBuildComment(f.Comment, &f.Moderation),
+			Moderation: protoBuildCommentModeration(f.Moderation),
+		}
+	}
+	nextPageToken, err := encodeFeedPageToken(storageResponse.Next, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to create page token")
+	}
+	response := &proto.ListFlaggedCommentsResponse{
+		Flagged:       flagged,
+		NextPageToken: nextPageToken,
+	}
+	return response, nil
+}
+
+func (a *adminServer) CreateCommentVerdict(ctx context.Context, request *proto.CreateCommentVerdictRequest) (*proto.CreateCommentVerdictResponse, error) {
+	err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commentID, err := uuid.Parse(request.GetCommentId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid comment ID")
+	}
+	if request.GetReviewer() == "" {
+		return nil, status.Error(codes.InvalidArgument, "reviewer must not be empty")
+	}
+	if len(request.GetReviewer()) > maxReviewerLength {
+		return nil, status.Errorf(codes.InvalidArgument, "reviewer must not be longer than %d characters", maxReviewerLength)
+	}
+	if request.GetNote() == "" {
+		return nil, status.Error(codes.InvalidArgument, "note must not be empty")
+	}
+	if len(request.GetNote()) > maxReviewerNoteLength {
+		return nil, status.Errorf(codes.InvalidArgument, "note must not be longer than %d characters", maxReviewerNoteLength)
+	}
+
+	tx, err := beginTx(ctx, a.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	storageRequest := storage.CreateCommentVerdictRequest{
+		CommentID: commentID,
+		Censored:  request.GetCensored(),
+		Note:      request.GetNote(),
+		Reviewer:  request.GetReviewer(),
+		Now:       a.clock.Now(),
+	}
+	moderation, err := storage.CreateCommentVerdict(ctx, tx, storageRequest)
+	if err != nil {
+		if errors.Is(err, storage.ErrCommentNotFound) {
+			return nil, status.Error(codes.NotFound, "comment not found")
+		}
+		return nil, status.Error(codes.Internal, "unable to create comment verdict")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to commit database transaction")
+	}
+
+	a.l.InfoContext(ctx, "Created manual comment verdict.",
+		"commentID", commentID,
+		"censored", moderation.Censored,
+		"reviewer", moderation.Reviewer,
+	)
+	response := &proto.CreateCommentVerdictResponse{
+		Moderation: protoBuildCommentModeration(moderation),
+	}
+	return response, nil
+}
+
+func (a *adminServer) GetCommentModerationHistory(ctx context.Context, request *proto.GetCommentModerationHistoryRequest) (*proto.GetCommentModerationHistoryResponse, error) {
+	err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commentID, err := uuid.Parse(request.GetCommentId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid comment ID")
+	}
+
+	tx, err := beginTx(ctx, a.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	comment, err := storage.ReadComment(ctx, tx, commentID)
+	if err != nil {
+		if errors.Is(err, storage.ErrCommentNotFound) {
+			return nil, status.Error(codes.NotFound, "comment not found")
+		}
+		return nil, status.Error(codes.Internal, "unable to read comment")
+	}
+	history, err := storage.ReadCommentModerationHistory(ctx, tx, commentID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to read comment moderation history")
+	}
+	revisions, err := storage.ReadCommentRevisions(ctx, tx, commentID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to read comment revisions")
+	}
+
+	protoHistory := make([]*proto.CommentModeration, len(history))
+	for i, m := range history {
+		protoHistory[i] = protoBuildCommentModeration(m)
+	}
+	var latest *storage.CommentModeration
+	if len(history) > 0 {
+		latest = &history[len(history)-1]
+	}
+	response := &proto.GetCommentModerationHistoryResponse{
+		Comment:   protoBuildComment(comment, latest),
+		History:   protoHistory,
+		Revisions: make([]*proto.CommentRevision, len(revisions)),
+	}
+	for i, r := range revisions {
+		response.Revisions[i] = &proto.CommentRevision{
+			Created:  timestamppb.New(r.Created),
+			Revision: int32(r.Revision),
+			Message:  r.Message,
+		}
+	}
+	return response, nil
+}
+
+func (a *adminServer) authenticate(ctx context.Context) error {
+	if a.c.AdminToken == "" {
+		return status.Error(codes.Unauthenticated, "admin service is disabled")
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing required metadata in gRPC request")
+	}
+	slice := md.Get(ipcoin.GRPCMetadataKeyAuthorization)
+	if len(slice) == 0 {
+		return status.Error(codes.Unauthenticated, "no authorization in metadata")
+	}
+	token, ok := strings.CutPrefix(slice[0], "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.c.AdminToken)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+	return nil
+}
+
+func protoBuildCommentModeration(m storage.CommentModeration) *proto.CommentModeration {
+	return &proto.CommentModeration{
+		Created:    timestamppb.New(m.Created),
+		Id:         m.ID.String(),
+		CommentId:  m.CommentID.String(),
+		Censored:   m.Censored,
+		Note:       m.Note,
+		Reviewer:   m.Reviewer,
+		Categories: protoBuildModerationCategories(m.Categories),
+		Revision:   int32(m.Revision),
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/MicahParks/ipcoin"
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+func TestAdminServer_CommentVerdict(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const token = "test-admin-token"
+	admin := NewAdmin(ipcoin.Config{AdminToken: token}, NewFakeClock(now), slog.Default(), pool)
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		for _, md := range []metadata.MD{
+			metadata.Pairs(),
+			metadata.Pairs(ipcoin.GRPCMetadataKeyAuthorization, "Bearer wrong"),
+			metadata.Pairs(ipcoin.GRPCMetadataKeyAuthorization, token),
+		} {
+			ctx := metadata.NewIncomingContext(ctx, md)
+			_, err := admin.ListFlaggedComments(ctx, &proto.ListFlaggedCommentsRequest{})
+			if status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("Should have unauthenticated error.\n  Error: %s", err)
+			}
+		}
+	})
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ipcoin.GRPCMetadataKeyAuthorization, "Bearer "+token))
+	ctx, tx := addTx(ctx, t)
+	defer tx.Rollback(ctx)
+
+	commentResponse, err := storage.CreateComment(ctx, tx, storage.CreateCommentRequest{
+		Addr:    netip.MustParseAddr("192.168.10.1"),
+		Message: "false positive",
+		Now:     now,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create comment.\n  Error: %s", err)
+	}
+	comment := commentResponse.Comment
+	automated := []storage.CommentModeration{{
+		CommentID: comment.ID,
+		Censored:  true,
+		Note:      "Moderated by OpenAI.",
+	}}
+	err = storage.CreateCommentModeration(ctx, tx, automated, now)
+	if err != nil {
+		t.Fatalf("Failed to create comment moderation.\n  Error: %s", err)
+	}
+
+	listResponse, err := admin.ListFlaggedComments(ctx, &proto.ListFlaggedCommentsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list flagged comments.\n  Error: %s", err)
+	}
+	found := false
+	for _, f := range listResponse.GetFlagged() {
+		if f.GetComment().GetId() == comment.ID.String() {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Censored comment should be flagged.")
+	}
+
+	verdictRequest := &proto.CreateCommentVerdictRequest{
+		CommentId: comment.ID.String(),
+		Censored:  false,
+		Reviewer:  "moderator@example.com",
+		Note:      "Not offensive.",
+	}
+	_, err = admin.CreateCommentVerdict(ctx, verdictRequest)
+	if err != nil {
+		t.Fatalf("Failed to create comment verdict.\n  Error: %s", err)
+	}
+
+	censored, err := storage.ReadCommentCensored(ctx, tx, []uuid.UUID{comment.ID})
+	if err != nil {
+		t.Fatalf("Failed to read censored comments.\n  Error: %s", err)
+	}
+	if len(censored) != 0 {
+		t.Fatalf("Latest manual verdict should uncensor the comment.")
+	}
+
+	historyResponse, err := admin.GetCommentModerationHistory(ctx, &proto.GetCommentModerationHistoryRequest{
+		CommentId: comment.ID.String(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to get comment moderation history.\n  Error: %s", err)
+	}
+	history := historyResponse.GetHistory()
+	if len(history) != 2 {
+		t.Fatalf("Unexpected history length.\n  Expected: 2\n  Actual: %d", len(history))
+	}
+	if history[1].GetReviewer() != verdictRequest.GetReviewer() || history[1].GetCensored() {
+		t.Fatalf("Latest verdict should be the manual verdict.")
+	}
+	if historyResponse.GetComment().GetCensored() {
+		t.Fatalf("Comment should not be censored.")
+	}
+
+	verdictRequest.CommentId = uuid.New().String()
+	_, err = admin.CreateCommentVerdict(ctx, verdictRequest)
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Should have not found error.\n  Error: %s", err)
+	}
+}
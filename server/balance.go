@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
+	"net/netip"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -51,3 +54,108 @@ func (s *server) GetBalance(ctx context.Context, _ *proto.GetBalanceRequest) (*p
 	}
 	return response, nil
 }
+
+func (s *server) GetBalanceHistory(ctx context.Context, request *proto.GetBalanceHistoryRequest) (*proto.GetBalanceHistoryResponse, error) {
+	from, err := s.getPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.readLimiter.Wait(ctx, from)
+	if err != nil {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	address := from
+	if request.GetAddress() != nil {
+		var ok bool
+		address, ok = netip.AddrFromSlice(request.GetAddress())
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "invalid address")
+		}
+	}
+	var interval time.Duration
+	switch request.GetInterval() {
+	case proto.BalanceHistoryInterval_BALANCE_HISTORY_INTERVAL_UNSPECIFIED, proto.BalanceHistoryInterval_BALANCE_HISTORY_INTERVAL_HOUR:
+		interval = time.Hour
+	case proto.BalanceHistoryInterval_BALANCE_HISTORY_INTERVAL_DAY:
+		interval = 24 * time.Hour
+	default:
+		return nil, status.Error(codes.InvalidArgument, "invalid interval")
+	}
+
+	now := s.clock.Now()
+	at := now
+	if request.GetAt() != nil {
+		at = request.GetAt().AsTime()
+	}
+	if at.After(now) {
+		return nil, status.Error(codes.InvalidArgument, "balance time must not be in the future")
+	}
+	if (request.GetStart() == nil) != (request.GetEnd() == nil) {
+		return nil, status.Error(codes.InvalidArgument, "series start and end must both be set or both be unset")
+	}
+	withSeries := request.GetStart() != nil
+	if withSeries && request.GetStart().AsTime().After(request.GetEnd().AsTime()) {
+		return nil, status.Error(codes.InvalidArgument, "series start must not be after series end")
+	}
+	if withSeries && request.GetEnd().AsTime().After(now) {
+		return nil, status.Error(codes.InvalidArgument, "series end must not be in the future")
+	}
+
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	dbReq := storage.GetBalanceHistoryRequest{
+		Address:  address,
+		Start:    at,
+		End:      at,
+		Interval: interval,
+	}
+	points, err := storage.GetBalanceHistory(ctx, tx, dbReq)
+	switch {
+	case errors.Is(err, storage.ErrBalanceHistoryRange):
+		return nil, status.Error(codes.InvalidArgument, "invalid balance time")
+	case err != nil:
+		return nil, status.Error(codes.Internal, "unable to get balance history")
+	}
+	response := &proto.GetBalanceHistoryResponse{
+		Balance: &proto.Balance{
+			Timestamp: timestamppb.New(points[0].Timestamp),
+			Address:   address.AsSlice(),
+			Available: points[0].Available,
+		},
+	}
+
+	if withSeries {
+		dbReq = storage.GetBalanceHistoryRequest{
+			Address:  address,
+			Start:    request.GetStart().AsTime(),
+			End:      request.GetEnd().AsTime(),
+			Interval: interval,
+		}
+		points, err = storage.GetBalanceHistory(ctx, tx, dbReq)
+		switch {
+		case errors.Is(err, storage.ErrBalanceHistoryRange):
+			return nil, status.Error(codes.InvalidArgument, "invalid balance history range")
+		case err != nil:
+			return nil, status.Error(codes.Internal, "unable to get balance history")
+		}
+		response.Series = make([]*proto.Balance, len(points))
+		for i, p := range points {
+			response.Series[i] = &proto.Balance{
+				Timestamp: timestamppb.New(p.Timestamp),
+				Address:   address.AsSlice(),
+				Available: p.Available,
+			}
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to commit database transaction")
+	}
+	return response, nil
+}
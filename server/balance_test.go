@@ -10,6 +10,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/MicahParks/ipcoin/ctxkey"
 	"github.com/MicahParks/ipcoin/proto"
@@ -65,6 +66,30 @@ func TestServer_GetBalanceNoPeer(t *testing.T) {
 	}
 }
 
+func TestServer_GetBalanceHistory_SeriesRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	p := &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("192.168.21.1").To4()},
+	}
+	ctx = context.WithValue(ctx, ctxkey.TestingPeer, p)
+
+	earlier := timestamppb.New(now.Add(-2 * time.Hour))
+	later := timestamppb.New(now.Add(-time.Hour))
+	requests := map[string]*proto.GetBalanceHistoryRequest{
+		"start only":        {Start: earlier},
+		"end only":          {End: later},
+		"start after end":   {Start: later, End: earlier},
+		"end in the future": {Start: earlier, End: timestamppb.New(now.Add(time.Hour))},
+	}
+	for name, request := range requests {
+		_, err := s.GetBalanceHistory(ctx, request)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("Series with %s should be an invalid argument.\n  Error: %s", name, err)
+		}
+	}
+}
+
 func Test_getPeer(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -21,6 +23,22 @@ func (s *server) CreateComment(ctx context.Context, request *proto.CreateComment
 		return nil, status.Errorf(codes.InvalidArgument, "comment must not be longer than %d characters", maxCommentLength)
 	}
 
+	var parentCommentID, parentTransferID *uuid.UUID
+	switch parent := request.GetParent().(type) {
+	case *proto.CreateCommentRequest_ParentCommentId:
+		id, err := uuid.Parse(parent.ParentCommentId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid parent comment ID")
+		}
+		parentCommentID = &id
+	case *proto.CreateCommentRequest_ParentTransferId:
+		id, err := uuid.Parse(parent.ParentTransferId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid parent transfer ID")
+		}
+		parentTransferID = &id
+	}
+
 	address, err := s.getPeer(ctx)
 	if err != nil {
 		return nil, err
@@ -41,9 +59,15 @@ func (s *server) CreateComment(ctx context.Context, request *proto.CreateComment
 		Addr:    address,
 		Message: request.GetComment(),
 		Now:     now,
+
+		ParentCommentID:  parentCommentID,
+		ParentTransferID: parentTransferID,
 	}
 	storageResponse, err := storage.CreateComment(ctx, tx, storageRequest)
 	if err != nil {
+		if errors.Is(err, storage.ErrCommentParentNotFound) {
+			return nil, status.Error(codes.NotFound, "parent not found")
+		}
 		return nil, status.Error(codes.Internal, "unable to create comment")
 	}
 
@@ -53,12 +77,153 @@ func (s *server) CreateComment(ctx context.Context, request *proto.CreateComment
 	}
 
 	response := &proto.CreateCommentResponse{
-		Comment: &proto.Comment{
-			Created: timestamppb.New(storageResponse.Comment.Created),
-			Id:      storageResponse.Comment.ID.String(),
-			Address: storageResponse.Comment.Address.AsSlice(),
-			Message: storageResponse.Comment.Message,
-		},
+		Comment: protoBuildComment(storageResponse.Comment, nil),
 	}
 	return response, nil
 }
+
+func (s *server) EditComment(ctx context.Context, request *proto.EditCommentRequest) (*proto.EditCommentResponse, error) {
+	commentID, err := uuid.Parse(request.GetCommentId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid comment ID")
+	}
+	if len(request.GetComment()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "comment must not be empty")
+	}
+	if len(request.GetComment()) > maxCommentLength {
+		return nil, status.Errorf(codes.InvalidArgument, "comment must not be longer than %d characters", maxCommentLength)
+	}
+
+	address, err := s.getPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.writeLimiter.Wait(ctx, address)
+	if err != nil {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	storageRequest := storage.EditCommentRequest{
+		CommentID: commentID,
+		Address:   address,
+		Message:   request.GetComment(),
+		Now:       s.clock.Now(),
+	}
+	c, err := storage.EditComment(ctx, tx, storageRequest)
+	if err != nil {
+		return nil, statusFromCommentAuthorErr(err, "unable to edit comment")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to commit database transaction")
+	}
+
+	response := &proto.EditCommentResponse{
+		Comment: protoBuildComment(c, nil),
+	}
+	return response, nil
+}
+
+func (s *server) DeleteComment(ctx context.Context, request *proto.DeleteCommentRequest) (*proto.DeleteCommentResponse, error) {
+	commentID, err := uuid.Parse(request.GetCommentId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid comment ID")
+	}
+
+	address, err := s.getPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.writeLimiter.Wait(ctx, address)
+	if err != nil {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	storageRequest := storage.DeleteCommentRequest{
+		CommentID: commentID,
+		Address:   address,
+		Now:       s.clock.Now(),
+	}
+	c, err := storage.DeleteComment(ctx, tx, storageRequest)
+	if err != nil {
+		return nil, statusFromCommentAuthorErr(err, "unable to delete comment")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to commit database transaction")
+	}
+
+	response := &proto.DeleteCommentResponse{
+		Comment: protoBuildComment(c, nil),
+	}
+	return response, nil
+}
+
+func statusFromCommentAuthorErr(err error, internalMsg string) error {
+	switch {
+	case errors.Is(err, storage.ErrCommentNotFound):
+		return status.Error(codes.NotFound, "comment not found")
+	case errors.Is(err, storage.ErrCommentNotAuthor):
+		return status.Error(codes.PermissionDenied, "only the address that wrote the comment may change it")
+	case errors.Is(err, storage.ErrCommentDeleted):
+		return status.Error(codes.FailedPrecondition, "comment is deleted")
+	default:
+		return status.Error(codes.Internal, internalMsg)
+	}
+}
+
+// protoBuildComment builds a comment with its latest verdict. The moderation is nil for unmoderated comments.
+func protoBuildComment(c storage.Comment, moderation *storage.CommentModeration) *proto.Comment {
+	comment := &proto.Comment{
+		Created: timestamppb.New(c.Created),
+		Id:      c.ID.String(),
+		Address: c.Address.AsSlice(),
+		Message: c.Message,
+	}
+	if c.ParentCommentID != nil {
+		comment.ParentCommentId = c.ParentCommentID.String()
+	}
+	if c.ParentTransferID != nil {
+		comment.ParentTransferId = c.ParentTransferID.String()
+	}
+	if c.Edited != nil {
+		comment.Edited = timestamppb.New(*c.Edited)
+	}
+	if c.Deleted != nil {
+		comment.Deleted = timestamppb.New(*c.Deleted)
+	}
+	if moderation != nil {
+		comment.Censored = moderation.Censored
+		comment.Moderation = &proto.ContentModeration{
+			Censored:   moderation.Censored,
+			Categories: protoBuildModerationCategories(moderation.Categories),
+		}
+	}
+	return comment
+}
+
+func protoBuildModerationCategories(categories []storage.ModerationCategory) []*proto.ModerationCategory {
+	p := make([]*proto.ModerationCategory, len(categories))
+	for i, c := range categories {
+		p[i] = &proto.ModerationCategory{
+			Name:    c.Name,
+			Flagged: c.Flagged,
+			Score:   c.Score,
+		}
+	}
+	return p
+}
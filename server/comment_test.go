@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/MicahParks/ipcoin/ctxkey"
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+func TestServer_EditDeleteComment(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ctx, tx := addTx(ctx, t)
+	defer tx.Rollback(ctx)
+	authorCtx := context.WithValue(ctx, ctxkey.TestingPeer, &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.5")},
+	})
+	otherCtx := context.WithValue(ctx, ctxkey.TestingPeer, &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.6")},
+	})
+
+	createResponse, err := s.CreateComment(authorCtx, &proto.CreateCommentRequest{Comment: "tpyo"})
+	if err != nil {
+		t.Fatalf("Failed to create comment.\n  Error: %s", err)
+	}
+	commentID := createResponse.GetComment().GetId()
+	moderations := []storage.CommentModeration{{
+		CommentID: uuid.MustParse(commentID),
+		Note:      "test",
+	}}
+	err = storage.CreateCommentModeration(ctx, tx, moderations, now)
+	if err != nil {
+		t.Fatalf("Failed to create comment moderation.\n  Error: %s", err)
+	}
+
+	_, err = s.EditComment(otherCtx, &proto.EditCommentRequest{CommentId: commentID, Comment: "hijacked"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Should have permission denied error.\n  Error: %s", err)
+	}
+
+	editResponse, err := s.EditComment(authorCtx, &proto.EditCommentRequest{CommentId: commentID, Comment: "typo"})
+	if err != nil {
+		t.Fatalf("Failed to edit comment.\n  Error: %s", err)
+	}
+	if editResponse.GetComment().GetMessage() != "typo" || editResponse.GetComment().GetEdited() == nil {
+		t.Fatalf("Edited comment should have the new message and an edited timestamp.")
+	}
+	unmoderated, err := storage.ClaimCommentUnmoderated(ctx, tx, now, time.Minute, 1000)
+	if err != nil {
+		t.Fatalf("Failed to claim unmoderated comments.\n  Error: %s", err)
+	}
+	if !slices.ContainsFunc(unmoderated, func(c storage.Comment) bool { return c.ID.String() == commentID }) {
+		t.Fatalf("Edited comment should be moderated again.")
+	}
+	revisions, err := storage.ReadCommentRevisions(ctx, tx, uuid.MustParse(commentID))
+	if err != nil {
+		t.Fatalf("Failed to read comment revisions.\n  Error: %s", err)
+	}
+	if len(revisions) != 1 || revisions[0].Message != "tpyo" {
+		t.Fatalf("Revision history should have the original message.")
+	}
+
+	deleteResponse, err := s.DeleteComment(authorCtx, &proto.DeleteCommentRequest{CommentId: commentID})
+	if err != nil {
+		t.Fatalf("Failed to delete comment.\n  Error: %s", err)
+	}
+	if deleteResponse.GetComment().GetMessage() != "" || deleteResponse.GetComment().GetDeleted() == nil {
+		t.Fatalf("Deleted comment should be a tombstone.")
+	}
+
+	author := netip.MustParseAddr("127.0.0.5")
+	feed, err := storage.GetFeed(ctx, tx, storage.GetFeedRequest{Now: now, Address: &author})
+	if err != nil {
+		t.Fatalf("Failed to get feed.\n  Error: %s", err)
+	}
+	found := slices.ContainsFunc(feed.Feed.Comment, func(c storage.Comment) bool {
+		return c.ID.String() == commentID && c.Deleted != nil
+	})
+	if !found {
+		t.Fatalf("Feed should include the tombstone.")
+	}
+
+	_, err = s.EditComment(authorCtx, &proto.EditCommentRequest{CommentId: commentID, Comment: "again"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Should have failed precondition error.\n  Error: %s", err)
+	}
+}
@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"net/netip"
 	"slices"
 
@@ -25,47 +28,123 @@ func (s *server) GetFeed(ctx context.Context, request *proto.GetFeedRequest) (*p
 		address = &a
 	}
 
+	var prefix *netip.Prefix
+	if request.PrefixLength != nil {
+		if address == nil {
+			return nil, status.Error(codes.InvalidArgument, "prefix length requires an address")
+		}
+		p, err := addressPrefix(*address, request.GetPrefixLength())
+		if err != nil {
+			return nil, err
+		}
+		prefix = &p
+		address = nil
+	}
+
 	storageRequest := storage.GetFeedRequest{
-		Now:     now,
-		Address: address,
+		Now:       now,
+		Address:   address,
+		Prefix:    prefix,
+		MinAmount: request.MinAmount,
+		MaxAmount: request.MaxAmount,
+	}
+	if request.GetCreatedAfter() != nil {
+		t := request.GetCreatedAfter().AsTime()
+		storageRequest.CreatedAfter = &t
+	}
+	if request.GetCreatedBefore() != nil {
+		t := request.GetCreatedBefore().AsTime()
+		storageRequest.CreatedBefore = &t
+	}
+	switch request.GetDirection() {
+	case proto.StatementDirection_STATEMENT_DIRECTION_UNSPECIFIED:
+	case proto.StatementDirection_STATEMENT_DIRECTION_SENT:
+		storageRequest.Direction = storage.StatementDirectionSent
+	case proto.StatementDirection_STATEMENT_DIRECTION_RECEIVED:
+		storageRequest.Direction = storage.StatementDirectionReceived
+	default:
+		return nil, status.Error(codes.InvalidArgument, "invalid direction")
+	}
+	switch request.GetKind() {
+	case proto.FeedKind_FEED_KIND_UNSPECIFIED:
+	case proto.FeedKind_FEED_KIND_COMMENT:
+		storageRequest.Kind = storage.FeedKindComment
+	case proto.FeedKind_FEED_KIND_TRANSFER:
+		storageRequest.Kind = storage.FeedKindTransfer
+	default:
+		return nil, status.Error(codes.InvalidArgument, "invalid kind")
+	}
+	if request.GetPageToken() != "" {
+		token, err := decodeFeedPageToken(request.GetPageToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		storageRequest.CommentAfter = token.Comment
+		storageRequest.TransferAfter = token.Transfer
+		storageRequest.SkipComment = token.CommentDone
+		storageRequest.SkipTransfer = token.TransferDone
 	}
 	storageResponse, err := storage.GetFeed(ctx, s.pool, storageRequest)
 	if err != nil {
+		if errors.Is(err, storage.ErrFeedFilter) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "unable to get feed")
 	}
 
-	censoredIDs := make([]uuid.UUID, 0)
-	if len(storageResponse.Feed.Comment) > 0 {
-		commentIDs := make([]uuid.UUID, len(storageResponse.Feed.Comment))
-		for i, c := range storageResponse.Feed.Comment {
-			commentIDs[i] = c.ID
-		}
-		censoredIDs, err = storage.ReadCommentCensored(ctx, s.pool, commentIDs)
+	commentIDs := make([]uuid.UUID, len(storageResponse.Feed.Comment))
+	for i, c := range storageResponse.Feed.Comment {
+		commentIDs[i] = c.ID
+	}
+	moderations := make(map[uuid.UUID]storage.CommentModeration)
+	if len(commentIDs) > 0 {
+		moderations, err = storage.ReadCommentModerationLatest(ctx, s.pool, commentIDs)
 		if err != nil {
 			return nil, status.Error(codes.Internal, "unable to read comment moderation")
 		}
 	}
 
+	censoredMemoIDs := make([]uuid.UUID, 0)
+	memoTransferIDs := make([]uuid.UUID, 0)
+	for _, t := range storageResponse.Feed.Transfer {
+		if t.Memo != "" {
+			memoTransferIDs = append(memoTransferIDs, t.ID)
+		}
+	}
+	if len(memoTransferIDs) > 0 {
+		censoredMemoIDs, err = storage.ReadTransferMemoCensored(ctx, s.pool, memoTransferIDs)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "unable to read transfer moderation")
+		}
+	}
+
+	transferIDs := make([]uuid.UUID, len(storageResponse.Feed.Transfer))
+	for i, t := range storageResponse.Feed.Transfer {
+		transferIDs[i] = t.ID
+	}
+	reactions, err := storage.ReadReactionCounts(ctx, s.pool, commentIDs, transferIDs)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to read reactions")
+	}
+
 	comment := make([]*proto.Comment, len(storageResponse.Feed.Comment))
 	for i, c := range storageResponse.Feed.Comment {
-		protoComment := &proto.Comment{
-			Created:  timestamppb.New(c.Created),
-			Id:       c.ID.String(),
-			Address:  c.Address.AsSlice(),
-			Message:  c.Message,
-			Censored: slices.Contains(censoredIDs, c.ID),
+		var moderation *storage.CommentModeration
+		m, ok := moderations[c.ID]
+		if ok {
+			moderation = &m
 		}
-		comment[i] = protoComment
+		comment[i] = protoBuildComment(c, moderation)
+		comment[i].Reactions = protoBuildReactionCounts(reactions.Comment[c.ID])
 	}
 	transfer := make([]*proto.Transfer, len(storageResponse.Feed.Transfer))
 	for i, t := range storageResponse.Feed.Transfer {
-		transfer[i] = &proto.Transfer{
-			Created:          timestamppb.New(t.Created),
-			Id:               t.ID.String(),
-			SenderAddress:    t.Sender.AsSlice(),
-			RecipientAddress: t.Recipient.AsSlice(),
-			Amount:           t.Amount,
-		}
+		transfer[i] = protoBuildTransfer(t, slices.Contains(censoredMemoIDs, t.ID))
+		transfer[i].Reactions = protoBuildReactionCounts(reactions.Transfer[t.ID])
+	}
+	nextPageToken, err := encodeFeedPageToken(storageResponse.CommentNext, storageResponse.TransferNext)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to create page token")
 	}
 	response := &proto.GetFeedResponse{
 		Feed: &proto.Feed{
@@ -73,6 +152,75 @@ func (s *server) GetFeed(ctx context.Context, request *proto.GetFeedRequest) (*p
 			Comment:   comment,
 			Transfer:  transfer,
 		},
+		NextPageToken: nextPageToken,
 	}
 	return response, nil
 }
+
+// feedPageToken holds a separate cursor for the comment and transfer feeds. A feed is done when it has no older rows,
+// which is different from a nil cursor that starts from the newest row.
+type feedPageToken struct {
+	Comment      *storage.FeedCursor `json:"c,omitempty"`
+	CommentDone  bool                `json:"cd,omitempty"`
+	Transfer     *storage.FeedCursor `json:"t,omitempty"`
+	TransferDone bool                `json:"td,omitempty"`
+}
+
+func encodeFeedPageToken(comment, transfer *storage.FeedCursor) (string, error) {
+	if comment == nil && transfer == nil {
+		return "", nil
+	}
+	token := feedPageToken{
+		Comment:      comment,
+		CommentDone:  comment == nil,
+		Transfer:     transfer,
+		TransferDone: transfer == nil,
+	}
+	b, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeFeedPageToken(s string) (feedPageToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return feedPageToken{}, err
+	}
+	var token feedPageToken
+	err = json.Unmarshal(b, &token)
+	if err != nil {
+		return feedPageToken{}, err
+	}
+	return token, nil
+}
+
+// offsetPageToken pages results that are ordered by a value with no stable cursor, such as a search rank.
+type offsetPageToken struct {
+	Offset int `json:"o"`
+}
+
+func encodeOffsetPageToken(offset *int) (string, error) {
+	if offset == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(offsetPageToken{Offset: *offset})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeOffsetPageToken(s string) (offsetPageToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return offsetPageToken{}, err
+	}
+	var token offsetPageToken
+	err = json.Unmarshal(b, &token)
+	if err != nil {
+		return offsetPageToken{}, err
+	}
+	return token, nil
+}
@@ -2,7 +2,10 @@ package server
 
 import (
 	"context"
+	"math"
+	"math/big"
 	"net/netip"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -34,6 +37,14 @@ func (s *server) GetGlance(ctx context.Context, request *proto.GetGlanceRequest)
 		}
 	}
 
+	if request.PrefixLength != nil {
+		prefix, err := addressPrefix(address, request.GetPrefixLength())
+		if err != nil {
+			return nil, err
+		}
+		return s.getGlancePrefix(ctx, prefix)
+	}
+
 	tx, err := s.tx(ctx)
 	if err != nil {
 		return nil, err
@@ -67,3 +78,69 @@ func (s *server) GetGlance(ctx context.Context, request *proto.GetGlanceRequest)
 	}
 	return response, nil
 }
+
+func (s *server) getGlancePrefix(ctx context.Context, prefix netip.Prefix) (*proto.GetGlanceResponse, error) {
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	now := s.clock.Now()
+	dbReq := storage.GetGlancePrefixRequest{
+		Prefix: prefix,
+		Now:    now,
+	}
+	glance, balanceUntouched, err := storage.GetGlancePrefix(ctx, tx, dbReq)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to get glance for prefix")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to commit database transaction")
+	}
+
+	response := &proto.GetGlanceResponse{
+		Glance:           protoBuildGlancePrefix(glance, now),
+		BalanceUntouched: balanceUntouched,
+	}
+	return response, nil
+}
+
+func protoBuildGlancePrefix(glance storage.GlancePrefix, now time.Time) *proto.Glance {
+	prefixLength := uint32(glance.Prefix.Bits())
+	return &proto.Glance{
+		Timestamp:             timestamppb.New(now),
+		Address:               glance.Prefix.Addr().AsSlice(),
+		BalanceAvailable:      saturateInt64(glance.BalanceAvailable),
+		CommentCount:          glance.CommentCount,
+		TransferCount:         glance.TransferCount,
+		PrefixLength:          &prefixLength,
+		BalanceAvailableExact: glance.BalanceAvailable.String(),
+		HostCount:             glance.HostCount.String(),
+	}
+}
+
+// addressPrefix returns the prefix of the given length containing the address.
+func addressPrefix(address netip.Addr, prefixLength uint32) (netip.Prefix, error) {
+	if prefixLength > uint32(address.BitLen()) {
+		return netip.Prefix{}, status.Errorf(codes.InvalidArgument, "prefix length must not be greater than %d", address.BitLen())
+	}
+	prefix, err := address.Prefix(int(prefixLength))
+	if err != nil {
+		return netip.Prefix{}, status.Error(codes.InvalidArgument, "invalid prefix")
+	}
+	return prefix, nil
+}
+
+func saturateInt64(i *big.Int) int64 {
+	switch {
+	case i.IsInt64():
+		return i.Int64()
+	case i.Sign() > 0:
+		return math.MaxInt64
+	default:
+		return math.MinInt64
+	}
+}
@@ -26,9 +26,10 @@ func (s *server) GetLeaderboard(ctx context.Context, request *proto.GetLeaderboa
 	if err != nil {
 		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
 	}
-	return s.leaderboardGetter.Get(ctx, request) // TODO Refresh MATERIALIZED VIEW occasionally.
+	return s.leaderboardGetter.Get(ctx, request)
 }
 
+// LeaderboardGetter serves GetLeaderboard. Passing nil to New reads the leaderboard from the database on every request.
 type LeaderboardGetter interface {
 	Get(ctx context.Context, request *proto.GetLeaderboardRequest) (*proto.GetLeaderboardResponse, error)
 }
@@ -39,18 +40,11 @@ type leaderboardDebug struct {
 
 func (l leaderboardDebug) Get(ctx context.Context, request *proto.GetLeaderboardRequest) (*proto.GetLeaderboardResponse, error) {
 	now := l.s.clock.Now()
-	storageRequest := storage.GetLeaderboardRequest{
-		Now: now,
-	}
-	err := storage.RefreshLeaderboard(ctx, l.s.pool, false)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to refresh leaderboard")
-	}
-	response, balanceUntouched, err := storage.GetLeaderboard(ctx, l.s.pool, storageRequest)
+	storageRequest, err := storageLeaderboardRequest(l.s.c, now, request)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "unable to get leaderboard")
+		return nil, err
 	}
-	return protoBuildGetLeaderboardResponse(response, balanceUntouched, now), nil
+	return getLeaderboard(ctx, l.s.pool, storageRequest)
 }
 
 type leaderboardNoOp struct{}
@@ -60,25 +54,34 @@ func (leaderboardNoOp) Get(_ context.Context, _ *proto.GetLeaderboardRequest) (*
 }
 
 type leaderboardMemCache struct {
-	mux      sync.RWMutex
-	response *proto.GetLeaderboardResponse
+	balanceUntouched int64
+	c                ipcoin.Config
+	mux              sync.RWMutex
+	now              time.Time
+	response         storage.GetLeaderboardResponse
 }
 
-func NewLeaderboardMemCache(ctx context.Context, pool *pgxpool.Pool) LeaderboardGetter {
+// NewLeaderboardMemCache creates a LeaderboardGetter that caches every page of the leaderboard and re-reads it every
+// minute. Requests never read the database.
+func NewLeaderboardMemCache(ctx context.Context, c ipcoin.Config, clock Clock, pool *pgxpool.Pool) LeaderboardGetter {
 	l := ctx.Value(ctxkey.Logger).(*slog.Logger)
 	cache := &leaderboardMemCache{
-		response: protoBuildGetLeaderboardResponse(storage.GetLeaderboardResponse{}, 0, time.Now()),
+		c:   c,
+		now: clock.Now(),
 	}
 	go func() {
 		for {
-			now := time.Now()
+			now := clock.Now()
 			nextRequest := now.Truncate(time.Minute).Add(time.Minute)
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.After(time.Until(nextRequest)):
+			case <-time.After(nextRequest.Sub(now)):
 				request := storage.GetLeaderboardRequest{
-					Now: nextRequest,
+					Now:        nextRequest,
+					PrefixIPv4: c.LeaderboardPrefixIPv4,
+					PrefixIPv6: c.LeaderboardPrefixIPv6,
+					Limit:      storage.LeaderboardMaxOffset + storage.LeaderboardMaxLimit,
 				}
 				response, balanceUntouched, err := storage.GetLeaderboard(ctx, pool, request)
 				if err != nil {
@@ -88,7 +91,9 @@ func NewLeaderboardMemCache(ctx context.Context, pool *pgxpool.Pool) Leaderboard
 					continue
 				}
 				cache.mux.Lock()
-				cache.response = protoBuildGetLeaderboardResponse(response, balanceUntouched, nextRequest)
+				cache.balanceUntouched = balanceUntouched
+				cache.now = request.Now
+				cache.response = response
 				cache.mux.Unlock()
 				l.DebugContext(ctx, "Leaderboard updated.")
 			}
@@ -97,43 +102,88 @@ func NewLeaderboardMemCache(ctx context.Context, pool *pgxpool.Pool) Leaderboard
 	return cache
 }
 
-func (c *leaderboardMemCache) Get(_ context.Context, _ *proto.GetLeaderboardRequest) (*proto.GetLeaderboardResponse, error) {
+func (c *leaderboardMemCache) Get(_ context.Context, request *proto.GetLeaderboardRequest) (*proto.GetLeaderboardResponse, error) {
 	c.mux.RLock()
 	defer c.mux.RUnlock()
-	return c.response, nil
+	storageRequest, err := storageLeaderboardRequest(c.c, c.now, request)
+	if err != nil {
+		return nil, err
+	}
+	page := c.response.Page(storageRequest.Offset, storageRequest.Limit)
+	return protoBuildGetLeaderboardResponse(page, c.balanceUntouched, c.now)
 }
 
-func protoBuildGetLeaderboardResponse(response storage.GetLeaderboardResponse, balanceUntouched int64, now time.Time) *proto.GetLeaderboardResponse {
-	leaderboardBalanceEntries := make([]*proto.Glance, len(response.LeaderboardBalance))
-	leaderboardTransferEntries := make([]*proto.Glance, len(response.LeaderboardTransfer))
-	for i, entry := range response.LeaderboardBalance {
-		leaderboardBalanceEntries[i] = &proto.Glance{
-			Timestamp:        timestamppb.New(now),
-			Address:          entry.Address.AsSlice(),
-			BalanceAvailable: entry.BalanceAvailable,
-			CommentCount:     entry.CommentCount,
-			TransferCount:    entry.TransferCount,
+// storageLeaderboardRequest caps the limit instead of rejecting it so clients can ask for "as many as possible".
+func storageLeaderboardRequest(c ipcoin.Config, now time.Time, request *proto.GetLeaderboardRequest) (storage.GetLeaderboardRequest, error) {
+	storageRequest := storage.GetLeaderboardRequest{
+		Now:        now,
+		PrefixIPv4: c.LeaderboardPrefixIPv4,
+		PrefixIPv6: c.LeaderboardPrefixIPv6,
+		Limit:      int(min(request.GetLimit(), storage.LeaderboardMaxLimit)),
+	}
+	if request.GetPageToken() != "" {
+		token, err := decodeOffsetPageToken(request.GetPageToken())
+		if err != nil || token.Offset < 0 || token.Offset > storage.LeaderboardMaxOffset {
+			return storage.GetLeaderboardRequest{}, status.Error(codes.InvalidArgument, "invalid page token")
 		}
+		storageRequest.Offset = token.Offset
 	}
-	for i, entry := range response.LeaderboardTransfer {
-		leaderboardTransferEntries[i] = &proto.Glance{
-			Timestamp:        timestamppb.New(now),
-			Address:          entry.Address.AsSlice(),
-			BalanceAvailable: entry.BalanceAvailable,
-			CommentCount:     entry.CommentCount,
-			TransferCount:    entry.TransferCount,
+	return storageRequest, nil
+}
+
+func getLeaderboard(ctx context.Context, pool *pgxpool.Pool, request storage.GetLeaderboardRequest) (*proto.GetLeaderboardResponse, error) {
+	response, balanceUntouched, err := storage.GetLeaderboard(ctx, pool, request)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to get leaderboard")
+	}
+	return protoBuildGetLeaderboardResponse(response, balanceUntouched, request.Now)
+}
+
+func protoBuildGetLeaderboardResponse(response storage.GetLeaderboardResponse, balanceUntouched int64, now time.Time) (*proto.GetLeaderboardResponse, error) {
+	protoBuildGlances := func(glances []storage.Glance) []*proto.Glance {
+		entries := make([]*proto.Glance, len(glances))
+		for i, entry := range glances {
+			entries[i] = &proto.Glance{
+				Timestamp:        timestamppb.New(now),
+				Address:          entry.Address.AsSlice(),
+				BalanceAvailable: entry.BalanceAvailable,
+				CommentCount:     entry.CommentCount,
+				TransferCount:    entry.TransferCount,
+			}
+		}
+		return entries
+	}
+	protoBuildGlancePrefixes := func(glances []storage.GlancePrefix) []*proto.Glance {
+		entries := make([]*proto.Glance, len(glances))
+		for i, entry := range glances {
+			entries[i] = protoBuildGlancePrefix(entry, now)
 		}
+		return entries
+	}
+	nextPageToken, err := encodeOffsetPageToken(response.NextOffset)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to create page token")
 	}
 	return &proto.GetLeaderboardResponse{
 		Leaderboard: &proto.Leaderboard{
 			Timestamp:        timestamppb.New(now),
 			BalanceUntouched: balanceUntouched,
 			LeaderboardBalance: &proto.LeaderboardBalance{
-				Entries: leaderboardBalanceEntries,
+				Entries: protoBuildGlances(response.LeaderboardBalance),
 			},
 			LeaderboardTransfer: &proto.LeaderboardTransfer{
-				Entries: leaderboardTransferEntries,
+				Entries: protoBuildGlances(response.LeaderboardTransfer),
+			},
+			LeaderboardPrefixBalance: &proto.LeaderboardPrefixBalance{
+				Entries: protoBuildGlancePrefixes(response.LeaderboardPrefixBalance),
+			},
+			LeaderboardPrefixTransfer: &proto.LeaderboardPrefixTransfer{
+				Entries: protoBuildGlancePrefixes(response.LeaderboardPrefixTransfer),
+			},
+			LeaderboardComment: &proto.LeaderboardComment{
+				Entries: protoBuildGlances(response.LeaderboardComment),
 			},
 		},
-	}
+		NextPageToken: nextPageToken,
+	}, nil
 }
@@ -2,10 +2,14 @@ package server
 
 import (
 	"context"
+	"net"
 	"net/netip"
 	"testing"
 	"time"
 
+	"google.golang.org/grpc/peer"
+
+	"github.com/MicahParks/ipcoin/ctxkey"
 	"github.com/MicahParks/ipcoin/proto"
 	"github.com/MicahParks/ipcoin/storage"
 )
@@ -13,6 +17,7 @@ import (
 func TestServer_GetLeaderboard(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	ctx = context.WithValue(ctx, ctxkey.TestingPeer, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.168.22.1").To4()}})
 	request := &proto.GetLeaderboardRequest{}
 
 	ipA := netip.MustParseAddr("192.168.0.1")
@@ -54,7 +59,8 @@ func TestServer_GetLeaderboard(t *testing.T) {
 	serv := s
 	oldLeaderboardGetter := serv.leaderboardGetter
 	serv.leaderboardGetter = &leaderboardMemCache{
-		response: protoBuildGetLeaderboardResponse(storageResponse, 0, now),
+		now:      now,
+		response: storageResponse,
 	}
 	defer func() {
 		serv.leaderboardGetter = oldLeaderboardGetter
@@ -101,12 +107,36 @@ func TestServer_GetLeaderboard(t *testing.T) {
 	if transfer[1].GetTransferCount() != tCount1 {
 		t.Fatalf("Transfer leaderboard incorrect transfer count.\n  Expected: %d\n  Actual: %d", tCount1, transfer[1].GetTransferCount())
 	}
+
+	response, err = s.GetLeaderboard(ctx, &proto.GetLeaderboardRequest{Limit: 1})
+	if err != nil {
+		t.Fatalf("Failed to read leaderboard page.\n  Error: %s", err)
+	}
+	if len(response.GetLeaderboard().GetLeaderboardBalance().GetEntries()) != 1 || response.GetNextPageToken() == "" {
+		t.Fatalf("First page of one entry should be served from the cache with a next page token.")
+	}
+	response, err = s.GetLeaderboard(ctx, &proto.GetLeaderboardRequest{Limit: 1, PageToken: response.GetNextPageToken()})
+	if err != nil {
+		t.Fatalf("Failed to read leaderboard page.\n  Error: %s", err)
+	}
+	balance = response.GetLeaderboard().GetLeaderboardBalance().GetEntries()
+	if len(balance) != 1 || netIPMustParseSlice(balance[0].GetAddress()) != ipB || response.GetNextPageToken() != "" {
+		t.Fatalf("Second page should be the last balance entry from the cache.")
+	}
 }
 
 func TestServer_GetLeaderboard_Empty(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	ctx = context.WithValue(ctx, ctxkey.TestingPeer, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.168.22.2").To4()}})
 	request := &proto.GetLeaderboardRequest{}
+	serv := s
+	oldLeaderboardGetter := serv.leaderboardGetter
+	serv.leaderboardGetter = &leaderboardMemCache{now: now}
+	defer func() {
+		serv.leaderboardGetter = oldLeaderboardGetter
+	}()
+
 	response, err := s.GetLeaderboard(ctx, request)
 	if err != nil {
 		t.Fatalf("Failed to read leaderboard.\n  Error: %s", err)
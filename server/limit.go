@@ -6,9 +6,17 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/time/rate"
+
+	"github.com/MicahParks/ipcoin"
+	"github.com/MicahParks/ipcoin/storage"
 )
 
+// addressLimiterPostgresIdle is how long a bucket in PostgreSQL is kept after its last use. Like addressLimiterMem, it
+// must be long enough for every bucket to refill.
+const addressLimiterPostgresIdle = time.Hour
+
 type AddressLimiter interface {
 	Wait(ctx context.Context, addr netip.Addr) error
 }
@@ -54,3 +62,68 @@ func (a *addressLimiterMem) Wait(ctx context.Context, addr netip.Addr) error {
 	limiter.timer.Reset(a.deleteAfter)
 	return limiter.l.Wait(ctx)
 }
+
+type addressLimiterPostgres struct {
+	burst int
+	name  string
+	pool  *pgxpool.Pool
+	rate  rate.Limit
+}
+
+// NewAddressLimiterPostgres keeps its token buckets in PostgreSQL so every limiter with the same name shares one budget
+// per address, across replicas and restarts. Unlike addressLimiterMem, a token is not returned when the context is
+// canceled while waiting for it.
+func NewAddressLimiterPostgres(name string, burst int, rateLimit rate.Limit, pool *pgxpool.Pool) AddressLimiter {
+	return &addressLimiterPostgres{
+		burst: burst,
+		name:  name,
+		pool:  pool,
+		rate:  rateLimit,
+	}
+}
+
+func (a *addressLimiterPostgres) Wait(ctx context.Context, addr netip.Addr) error {
+	request := storage.TakeRateLimitTokenRequest{
+		Name:    a.name,
+		Address: addr,
+		Burst:   a.burst,
+		Rate:    float64(a.rate),
+	}
+	deadline, ok := ctx.Deadline()
+	if ok {
+		maxWait := time.Until(deadline)
+		request.MaxWait = &maxWait
+	}
+	wait, err := storage.TakeRateLimitToken(ctx, a.pool, request)
+	if err != nil {
+		return err
+	}
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// deleteRateLimitIdle periodically deletes the PostgreSQL rate limit buckets that have refilled.
+func (s *server) deleteRateLimitIdle(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(addressLimiterPostgresIdle):
+		}
+		_, err := storage.DeleteRateLimitIdle(ctx, s.pool, addressLimiterPostgresIdle)
+		if err != nil {
+			s.l.ErrorContext(ctx, "Failed to delete idle rate limits.",
+				ipcoin.LogErr, err,
+			)
+		}
+	}
+}
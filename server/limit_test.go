@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+func TestAddressLimiterPostgres_Shared(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Buckets are committed, so use names unique to this run and remove them afterward.
+	slow := "test-slow-" + uuid.NewString()
+	fast := "test-fast-" + uuid.NewString()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := pool.Exec(ctx, "DELETE FROM rate_limit WHERE name = ANY ($1)", []string{slow, fast})
+		if err != nil {
+			t.Errorf("Failed to delete rate limits.\n  Error: %s", err)
+		}
+	})
+
+	addr := netip.MustParseAddr("192.168.20.1")
+	first := NewAddressLimiterPostgres(slow, 2, rate.Every(time.Hour), pool)
+	second := NewAddressLimiterPostgres(slow, 2, rate.Every(time.Hour), pool)
+	for _, l := range []AddressLimiter{first, second} {
+		err := l.Wait(ctx, addr)
+		if err != nil {
+			t.Fatalf("Failed to wait for rate limit within burst.\n  Error: %s", err)
+		}
+	}
+	for _, l := range []AddressLimiter{first, second} {
+		waitCtx, waitCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		err := l.Wait(waitCtx, addr)
+		waitCancel()
+		if !errors.Is(err, storage.ErrRateLimitWait) {
+			t.Fatalf("Burst should be shared between limiters.\n  Error: %s", err)
+		}
+	}
+	err := first.Wait(ctx, netip.MustParseAddr("192.168.20.2"))
+	if err != nil {
+		t.Fatalf("Other addresses should have their own budget.\n  Error: %s", err)
+	}
+
+	refill := 200 * time.Millisecond
+	first = NewAddressLimiterPostgres(fast, 1, rate.Every(refill), pool)
+	second = NewAddressLimiterPostgres(fast, 1, rate.Every(refill), pool)
+	err = first.Wait(ctx, addr)
+	if err != nil {
+		t.Fatalf("Failed to wait for rate limit within burst.\n  Error: %s", err)
+	}
+	start := time.Now()
+	err = second.Wait(ctx, addr)
+	if err != nil {
+		t.Fatalf("Failed to wait for rate limit.\n  Error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < refill/2 {
+		t.Fatalf("Second limiter should wait for the token taken by the first.\n  Elapsed: %s", elapsed)
+	}
+}
@@ -36,7 +36,15 @@ func TestMain(m *testing.M) {
 	}
 	defer pool.Close()
 
-	s = New(ctx, ipcoin.Config{}, NewFakeClock(now), l, leaderboardNoOp{}, pool, "").(*server)
+	_, err = storage.MigrateUp(ctx, pool)
+	if err != nil {
+		l.ErrorContext(ctx, "Failed to migrate PostgreSQL.",
+			ipcoin.LogErr, err,
+		)
+		return
+	}
+
+	s = New(ctx, ipcoin.Config{}, NewFakeClock(now), l, leaderboardNoOp{}, nil, pool, "").(*server)
 
 	m.Run()
 }
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+
+	"github.com/MicahParks/ipcoin"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+// ErrModerationVerdictCount indicates a Moderator did not return one verdict per comment.
+var ErrModerationVerdictCount = errors.New("moderation verdict count does not match comment count")
+
+// ModerationVerdict is the decision a Moderator made for a single comment. Categories is optional.
+type ModerationVerdict struct {
+	Censored   bool
+	Note       string
+	Categories []storage.ModerationCategory
+}
+
+// Moderator decides which comments to censor. It must return exactly one verdict per comment, in the same order.
+type Moderator interface {
+	Moderate(ctx context.Context, comments []storage.Comment) ([]ModerationVerdict, error)
+}
+
+// NewConfigModerator creates the Moderator described by the config. The local moderator runs before OpenAI so obvious
+// matches never leave the network. A nil Moderator is returned when moderation is not configured.
+func NewConfigModerator(c ipcoin.Config) (Moderator, error) {
+	moderators := make([]Moderator, 0)
+	if c.ModerationRulesPath != "" {
+		local, err := NewModeratorLocalFile(c.ModerationRulesPath)
+		if err != nil {
+			return nil, err
+		}
+		moderators = append(moderators, local)
+	}
+	if c.OpenAIAPIKey != "" {
+		moderators = append(moderators, NewModeratorOpenAI(openai.NewClient(option.WithAPIKey(c.OpenAIAPIKey))))
+	}
+	switch len(moderators) {
+	case 0:
+		return nil, nil
+	case 1:
+		return moderators[0], nil
+	default:
+		return NewModeratorChain(moderators...), nil
+	}
+}
+
+type moderatorOpenAI struct {
+	client openai.Client
+}
+
+func NewModeratorOpenAI(client openai.Client) Moderator {
+	return moderatorOpenAI{
+		client: client,
+	}
+}
+
+func (m moderatorOpenAI) Moderate(ctx context.Context, comments []storage.Comment) ([]ModerationVerdict, error) {
+	inputs := make([]string, len(comments))
+	for i, c := range comments {
+		inputs[i] = c.Message
+	}
+	openaiCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	response, err := m.client.Moderations.New(openaiCtx, openai.ModerationNewParams{
+		Input: openai.ModerationNewParamsInputUnion{
+			OfStringArray: inputs,
+		},
+		Model: openai.ModerationModelOmniModerationLatest,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OpenAI moderation response: %w", err)
+	}
+	if len(response.Results) != len(comments) {
+		return nil, fmt.Errorf("%w: OpenAI returned %d results for %d comments", ErrModerationVerdictCount, len(response.Results), len(comments))
+	}
+	verdicts := make([]ModerationVerdict, len(comments))
+	for i, result := range response.Results {
+		verdicts[i] = ModerationVerdict{
+			Censored:   result.Flagged,
+			Note:       "Moderated by OpenAI.",
+			Categories: openaiCategories(result),
+		}
+	}
+	return verdicts, nil
+}
+
+// openaiCategories uses the category names from the OpenAI moderation API.
+func openaiCategories(result openai.Moderation) []storage.ModerationCategory {
+	c, score := result.Categories, result.CategoryScores
+	return []storage.ModerationCategory{
+		{Name: "harassment", Flagged: c.Harassment, Score: score.Harassment},
+		{Name: "harassment/threatening", Flagged: c.HarassmentThreatening, Score: score.HarassmentThreatening},
+		{Name: "hate", Flagged: c.Hate, Score: score.Hate},
+		{Name: "hate/threatening", Flagged: c.HateThreatening, Score: score.HateThreatening},
+		{Name: "illicit", Flagged: c.Illicit, Score: score.Illicit},
+		{Name: "illicit/violent", Flagged: c.IllicitViolent, Score: score.IllicitViolent},
+		{Name: "self-harm", Flagged: c.SelfHarm, Score: score.SelfHarm},
+		{Name: "self-harm/instructions", Flagged: c.SelfHarmInstructions, Score: score.SelfHarmInstructions},
+		{Name: "self-harm/intent", Flagged: c.SelfHarmIntent, Score: score.SelfHarmIntent},
+		{Name: "sexual", Flagged: c.Sexual, Score: score.Sexual},
+		{Name: "sexual/minors", Flagged: c.SexualMinors, Score: score.SexualMinors},
+		{Name: "violence", Flagged: c.Violence, Score: score.Violence},
+		{Name: "violence/graphic", Flagged: c.ViolenceGraphic, Score: score.ViolenceGraphic},
+	}
+}
+
+// ModerationRule censors comments matching Pattern. Name is recorded in the moderation note.
+type ModerationRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+type moderatorLocal struct {
+	rules []ModerationRule
+}
+
+// NewModeratorLocalFile creates a Moderator from a rules file. Each line is either a word or phrase, matched
+// case-insensitively on word boundaries, or a regular expression prefixed with "re:". Blank lines and lines starting
+// with "#" are ignored.
+func NewModeratorLocalFile(path string) (Moderator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open moderation rules file: %w", err)
+	}
+	defer f.Close()
+
+	rules := make([]ModerationRule, 0)
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var re *regexp.Regexp
+		if expr, ok := strings.CutPrefix(line, "re:"); ok {
+			re, err = regexp.Compile(expr)
+		} else {
+			re, err = regexp.Compile(`(?i)\b` + regexp.QuoteMeta(line) + `\b`)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile moderation rule on line %d: %w", lineNumber, err)
+		}
+		rules = append(rules, ModerationRule{
+			Name:    line,
+			Pattern: re,
+		})
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation rules file: %w", err)
+	}
+	return NewModeratorLocal(rules...), nil
+}
+
+// NewModeratorLocal creates a Moderator that censors any comment matching one of the given rules.
+func NewModeratorLocal(rules ...ModerationRule) Moderator {
+	return moderatorLocal{
+		rules: rules,
+	}
+}
+
+func (m moderatorLocal) Moderate(_ context.Context, comments []storage.Comment) ([]ModerationVerdict, error) {
+	verdicts := make([]ModerationVerdict, len(comments))
+	for i, c := range comments {
+		verdicts[i] = ModerationVerdict{
+			Note: "Moderated by local rules.",
+		}
+		for _, rule := range m.rules {
+			if rule.Pattern.MatchString(c.Message) {
+				verdicts[i] = ModerationVerdict{
+					Censored: true,
+					Note:     fmt.Sprintf("Moderated by local rules. Matched %q.", rule.Name),
+				}
+				break
+			}
+		}
+	}
+	return verdicts, nil
+}
+
+type moderatorChain struct {
+	moderators []Moderator
+}
+
+// NewModeratorChain creates a Moderator that asks each Moderator in order. A comment is censored by the first
+// Moderator that censors it and later Moderators never see it. The notes and categories of every Moderator that saw a
+// comment are kept.
+func NewModeratorChain(moderators ...Moderator) Moderator {
+	return moderatorChain{
+		moderators: moderators,
+	}
+}
+
+func (m moderatorChain) Moderate(ctx context.Context, comments []storage.Comment) ([]ModerationVerdict, error) {
+	verdicts := make([]ModerationVerdict, len(comments))
+	notes := make([][]string, len(comments))
+	remaining := make([]int, len(comments))
+	for i := range comments {
+		remaining[i] = i
+	}
+	for _, moderator := range m.moderators {
+		if len(remaining) == 0 {
+			break
+		}
+		batch := make([]storage.Comment, len(remaining))
+		for i, idx := range remaining {
+			batch[i] = comments[idx]
+		}
+		results, err := moderator.Moderate(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) != len(batch) {
+			return nil, fmt.Errorf("%w: got %d verdicts for %d comments", ErrModerationVerdictCount, len(results), len(batch))
+		}
+		next := remaining[:0]
+		for i, idx := range remaining {
+			notes[idx] = append(notes[idx], results[i].Note)
+			verdicts[idx].Categories = append(verdicts[idx].Categories, results[i].Categories...)
+			if results[i].Censored {
+				verdicts[idx].Censored = true
+				continue
+			}
+			next = append(next, idx)
+		}
+		remaining = next
+	}
+	for i := range verdicts {
+		verdicts[i].Note = strings.Join(notes[i], " ")
+	}
+	return verdicts, nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+type moderatorFunc func(ctx context.Context, comments []storage.Comment) ([]ModerationVerdict, error)
+
+func (f moderatorFunc) Moderate(ctx context.Context, comments []storage.Comment) ([]ModerationVerdict, error) {
+	return f(ctx, comments)
+}
+
+func TestModeratorLocalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	rules := "# Comment.\n\nbadword\nre:^spam[0-9]+$\n"
+	err := os.WriteFile(path, []byte(rules), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write rules file.\n  Error: %s", err)
+	}
+	moderator, err := NewModeratorLocalFile(path)
+	if err != nil {
+		t.Fatalf("Failed to create local moderator.\n  Error: %s", err)
+	}
+
+	comments := []storage.Comment{
+		{Message: "this has a BadWord in it"},
+		{Message: "badwords is not a whole word match"},
+		{Message: "spam123"},
+		{Message: "hello"},
+	}
+	verdicts, err := moderator.Moderate(context.Background(), comments)
+	if err != nil {
+		t.Fatalf("Failed to moderate comments.\n  Error: %s", err)
+	}
+	expected := []bool{true, false, true, false}
+	for i, v := range verdicts {
+		if v.Censored != expected[i] {
+			t.Fatalf("Unexpected verdict for %q.\n  Expected: %t\n  Actual: %t", comments[i].Message, expected[i], v.Censored)
+		}
+	}
+}
+
+func TestModeratorChain(t *testing.T) {
+	seen := 0
+	second := moderatorFunc(func(_ context.Context, comments []storage.Comment) ([]ModerationVerdict, error) {
+		seen += len(comments)
+		verdicts := make([]ModerationVerdict, len(comments))
+		for i := range verdicts {
+			verdicts[i] = ModerationVerdict{
+				Censored:   comments[i].Message == "second",
+				Note:       "second",
+				Categories: []storage.ModerationCategory{{Name: "second", Flagged: comments[i].Message == "second"}},
+			}
+		}
+		return verdicts, nil
+	})
+	first := moderatorFunc(func(_ context.Context, comments []storage.Comment) ([]ModerationVerdict, error) {
+		verdicts := make([]ModerationVerdict, len(comments))
+		for i := range verdicts {
+			verdicts[i] = ModerationVerdict{
+				Censored: comments[i].Message == "first",
+				Note:     "first",
+			}
+		}
+		return verdicts, nil
+	})
+	moderator := NewModeratorChain(first, second)
+
+	comments := []storage.Comment{
+		{Message: "first"},
+		{Message: "second"},
+		{Message: "neither"},
+	}
+	verdicts, err := moderator.Moderate(context.Background(), comments)
+	if err != nil {
+		t.Fatalf("Failed to moderate comments.\n  Error: %s", err)
+	}
+	if seen != 2 {
+		t.Fatalf("Censored comments should not be passed to later moderators.\n  Expected: 2\n  Actual: %d", seen)
+	}
+	expected := []ModerationVerdict{
+		{Censored: true, Note: "first"},
+		{Censored: true, Note: "first second"},
+		{Censored: false, Note: "first second"},
+	}
+	for i, v := range verdicts {
+		if v.Censored != expected[i].Censored || v.Note != expected[i].Note {
+			t.Fatalf("Unexpected verdict for %q.\n  Expected: %+v\n  Actual: %+v", comments[i].Message, expected[i], v)
+		}
+		if len(v.Categories) != strings.Count(v.Note, "second") {
+			t.Fatalf("Categories should be kept from every moderator that saw %q.", comments[i].Message)
+		}
+	}
+}
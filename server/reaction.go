@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+var reactionKinds = map[proto.ReactionKind]storage.ReactionKind{
+	proto.ReactionKind_REACTION_KIND_THUMBS_UP:   storage.ReactionKindThumbsUp,
+	proto.ReactionKind_REACTION_KIND_THUMBS_DOWN: storage.ReactionKindThumbsDown,
+	proto.ReactionKind_REACTION_KIND_HEART:       storage.ReactionKindHeart,
+	proto.ReactionKind_REACTION_KIND_LAUGH:       storage.ReactionKindLaugh,
+	proto.ReactionKind_REACTION_KIND_FIRE:        storage.ReactionKindFire,
+	proto.ReactionKind_REACTION_KIND_COIN:        storage.ReactionKindCoin,
+}
+
+// reactionRequest is implemented by CreateReactionRequest and DeleteReactionRequest.
+type reactionRequest interface {
+	GetCommentId() string
+	GetTransferId() string
+	GetKind() proto.ReactionKind
+}
+
+func (s *server) CreateReaction(ctx context.Context, request *proto.CreateReactionRequest) (*proto.CreateReactionResponse, error) {
+	storageRequest, err := s.reactionRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := storage.CreateReaction(ctx, tx, storageRequest)
+	if err != nil {
+		if errors.Is(err, storage.ErrReactionTargetNotFound) {
+			return nil, status.Error(codes.NotFound, "reaction target not found")
+		}
+		return nil, status.Error(codes.Internal, "unable to create reaction")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to commit database transaction")
+	}
+
+	response := &proto.CreateReactionResponse{
+		Created: created,
+	}
+	return response, nil
+}
+
+func (s *server) DeleteReaction(ctx context.Context, request *proto.DeleteReactionRequest) (*proto.DeleteReactionResponse, error) {
+	storageRequest, err := s.reactionRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	deleted, err := storage.DeleteReaction(ctx, tx, storageRequest)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to delete reaction")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to commit database transaction")
+	}
+
+	response := &proto.DeleteReactionResponse{
+		Deleted: deleted,
+	}
+	return response, nil
+}
+
+// reactionRequest validates the request and waits on the write limiter for the caller.
+func (s *server) reactionRequest(ctx context.Context, request reactionRequest) (storage.ReactionRequest, error) {
+	kind, ok := reactionKinds[request.GetKind()]
+	if !ok {
+		return storage.ReactionRequest{}, status.Error(codes.InvalidArgument, "invalid reaction kind")
+	}
+	var target storage.ReactionTarget
+	switch {
+	case request.GetCommentId() != "":
+		id, err := uuid.Parse(request.GetCommentId())
+		if err != nil {
+			return storage.ReactionRequest{}, status.Error(codes.InvalidArgument, "invalid comment ID")
+		}
+		target.CommentID = &id
+	case request.GetTransferId() != "":
+		id, err := uuid.Parse(request.GetTransferId())
+		if err != nil {
+			return storage.ReactionRequest{}, status.Error(codes.InvalidArgument, "invalid transfer ID")
+		}
+		target.TransferID = &id
+	default:
+		return storage.ReactionRequest{}, status.Error(codes.InvalidArgument, "reaction must target a comment or a transfer")
+	}
+
+	address, err := s.getPeer(ctx)
+	if err != nil {
+		return storage.ReactionRequest{}, err
+	}
+	err = s.writeLimiter.Wait(ctx, address)
+	if err != nil {
+		return storage.ReactionRequest{}, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	storageRequest := storage.ReactionRequest{
+		Address: address,
+		Target:  target,
+		Kind:    kind,
+		Now:     s.clock.Now(),
+	}
+	return storageRequest, nil
+}
+
+func protoBuildReactionCounts(counts []storage.ReactionCount) []*proto.ReactionCount {
+	p := make([]*proto.ReactionCount, 0, len(counts))
+	for _, c := range counts {
+		for protoKind, kind := range reactionKinds {
+			if kind == c.Kind {
+				p = append(p, &proto.ReactionCount{
+					Kind:  protoKind,
+					Count: c.Count,
+				})
+				break
+			}
+		}
+	}
+	return p
+}
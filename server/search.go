@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+const maxSearchQueryLength = 200
+
+func (s *server) SearchComments(ctx context.Context, request *proto.SearchCommentsRequest) (*proto.SearchCommentsResponse, error) {
+	from, err := s.getPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.readLimiter.Wait(ctx, from)
+	if err != nil {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	if strings.TrimSpace(request.GetQuery()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "query must not be empty")
+	}
+	if len(request.GetQuery()) > maxSearchQueryLength {
+		return nil, status.Errorf(codes.InvalidArgument, "query must not be longer than %d characters", maxSearchQueryLength)
+	}
+	storageRequest := storage.SearchCommentsRequest{
+		Query:           request.GetQuery(),
+		IncludeCensored: request.GetIncludeCensored(),
+	}
+	if request.Address != nil {
+		a, ok := netip.AddrFromSlice(request.GetAddress())
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "invalid address")
+		}
+		storageRequest.Address = &a
+	}
+	if request.GetCreatedAfter() != nil {
+		t := request.GetCreatedAfter().AsTime()
+		storageRequest.CreatedAfter = &t
+	}
+	if request.GetCreatedBefore() != nil {
+		t := request.GetCreatedBefore().AsTime()
+		storageRequest.CreatedBefore = &t
+	}
+	if request.GetPageToken() != "" {
+		token, err := decodeOffsetPageToken(request.GetPageToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		storageRequest.Offset = token.Offset
+	}
+
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	storageResponse, err := storage.SearchComments(ctx, tx, storageRequest)
+	if err != nil {
+		if errors.Is(err, storage.ErrSearchOffset) {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		return nil, status.Error(codes.Internal, "unable to search comments")
+	}
+
+	commentIDs := make([]uuid.UUID, len(storageResponse.Results))
+	for i, r := range storageResponse.Results {
+		commentIDs[i] = r.Comment.ID
+	}
+	moderations := make(map[uuid.UUID]storage.CommentModeration)
+	if len(commentIDs) > 0 {
+		moderations, err = storage.ReadCommentModerationLatest(ctx, tx, commentIDs)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "unable to read comment moderation")
+		}
+	}
+	reactions, err := storage.ReadReactionCounts(ctx, tx, commentIDs, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to read reactions")
+	}
+
+	results := make([]*proto.SearchCommentsResult, len(storageResponse.Results))
+	for i, r := range storageResponse.Results {
+		var moderation *storage.CommentModeration
+		m, ok := moderations[r.Comment.ID]
+		if ok {
+			moderation = &m
+		}
+		comment := protoBuildComment(r.Comment, moderation)
+		comment.Reactions = protoBuildReactionCounts(reactions.Comment[r.Comment.ID])
+		results[i] = &proto.SearchCommentsResult{
+			Comment: comment,
+			Rank:    r.Rank,
+		}
+	}
+	nextPageToken, err := encodeOffsetPageToken(storageResponse.NextOffset)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to create page token")
+	}
+	response := &proto.SearchCommentsResponse{
+		Results:       results,
+		NextPageToken: nextPageToken,
+	}
+	return response, nil
+}
@@ -2,19 +2,17 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/netip"
-	"slices"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/MicahParks/ipcoin/storage"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/openai/openai-go/v2"
-	"github.com/openai/openai-go/v2/option"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -24,15 +22,33 @@ import (
 	"github.com/MicahParks/ipcoin"
 	"github.com/MicahParks/ipcoin/ctxkey"
 	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+const (
+	// balanceCheckpointInterval is how often balance checkpoints are created.
+	balanceCheckpointInterval = time.Hour
+	// moderationBatchSize is the most comments or memos claimed by one moderation transaction.
+	moderationBatchSize = 100
+	// moderationInterval is how long the moderation worker sleeps when there is nothing left to moderate.
+	moderationInterval = time.Minute
+	// moderationLease is how long claimed comments and memos are skipped by other workers while they are moderated. It
+	// must be longer than a Moderator takes.
+	moderationLease = 5 * time.Minute
+	// moderationMaxBackoff caps how long a comment that keeps failing moderation waits between attempts.
+	moderationMaxBackoff = 6 * time.Hour
+	// moderationRetryBackoff is the first delay after a failed batch. It doubles for each consecutive failure.
+	moderationRetryBackoff = 5 * time.Second
 )
 
 type server struct {
 	addrLocker        *addrLocker
 	c                 ipcoin.Config
 	clock             Clock
+	feedHub           *feedHub
 	l                 *slog.Logger
 	leaderboardGetter LeaderboardGetter
-	openai            openai.Client
+	moderator         Moderator
 	pool              *pgxpool.Pool
 	readLimiter       AddressLimiter
 	runtimeKey        string
@@ -40,23 +56,34 @@ type server struct {
 	proto.UnimplementedIPCoinServiceServer
 }
 
-func New(ctx context.Context, c ipcoin.Config, clock Clock, l *slog.Logger, leaderboardGetter LeaderboardGetter, pool *pgxpool.Pool, runtimeKey string) proto.IPCoinServiceServer {
+func New(ctx context.Context, c ipcoin.Config, clock Clock, l *slog.Logger, leaderboardGetter LeaderboardGetter, moderator Moderator, pool *pgxpool.Pool, runtimeKey string) proto.IPCoinServiceServer {
 	s := &server{
 		addrLocker:        newAddrLocker(2 * time.Hour),
 		c:                 c,
 		clock:             clock,
+		feedHub:           newFeedHub(),
 		l:                 l,
 		leaderboardGetter: leaderboardGetter,
-		openai:            openai.NewClient(option.WithAPIKey(c.OpenAIAPIKey)),
+		moderator:         moderator,
 		pool:              pool,
 		readLimiter:       NewAddressLimiterMem(60, rate.Every(time.Second)),
 		runtimeKey:        runtimeKey,
 		writeLimiter:      NewAddressLimiterMem(6, rate.Every(10*time.Second)),
 	}
-	s.leaderboardGetter = leaderboardDebug{s: s}
+	if leaderboardGetter == nil {
+		s.leaderboardGetter = leaderboardDebug{s: s}
+	}
+	if c.RateLimitPostgres {
+		s.readLimiter = NewAddressLimiterPostgres("read", 60, rate.Every(time.Second), pool)
+		s.writeLimiter = NewAddressLimiterPostgres("write", 6, rate.Every(10*time.Second), pool)
+		go s.deleteRateLimitIdle(ctx)
+	}
 
-	if c.OpenAIAPIKey != "" {
-		go s.openaiModeration(ctx)
+	go s.listenFeed(ctx)
+	go s.checkpointBalances(ctx)
+
+	if moderator != nil {
+		go s.moderate(ctx)
 	}
 
 	return s
@@ -66,6 +93,9 @@ type addrLock struct {
 	ch          chan struct{}
 	deleteTimer *time.Timer
 }
+
+// addrLocker serializes requests from the same address within this process so they do not hold database connections
+// while waiting on the transfer lock in storage, which is what prevents double spends across processes.
 type addrLocker struct {
 	deleteAfter time.Duration
 	m           map[netip.Addr]addrLock
@@ -113,6 +143,10 @@ func (l *addrLocker) WithLock(ctx context.Context, addr netip.Addr, f func()) {
 }
 
 func (s *server) tx(ctx context.Context) (pgx.Tx, error) {
+	return beginTx(ctx, s.pool)
+}
+
+func beginTx(ctx context.Context, pool *pgxpool.Pool) (pgx.Tx, error) {
 	var err error
 	tx, ok := ctx.Value(ctxkey.TestingTx).(pgx.Tx)
 	if ok {
@@ -121,7 +155,7 @@ func (s *server) tx(ctx context.Context) (pgx.Tx, error) {
 			return nil, status.Error(codes.Internal, "unable to start nested testing database transaction")
 		}
 	} else {
-		tx, err = s.pool.Begin(ctx)
+		tx, err = pool.Begin(ctx)
 		if err != nil {
 			return nil, status.Error(codes.Internal, "unable to start database transaction")
 		}
@@ -227,70 +261,240 @@ func (s *server) getPeer(ctx context.Context) (from netip.Addr, err error) {
 	return from, nil
 }
 
-func (s *server) openaiModeration(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
+// moderate runs until the context is canceled. Full batches are followed immediately by another batch and failed
+// batches are retried after moderationRetryBackoff, doubled for each consecutive failure up to moderationMaxBackoff.
+func (s *server) moderate(ctx context.Context) {
+	failures := 0
 	for {
+		wait := moderationInterval
+		fullComments, commentErr := s.moderateComments(ctx)
+		if commentErr != nil {
+			s.l.ErrorContext(ctx, "Failed to moderate comments.",
+				ipcoin.LogErr, commentErr,
+			)
+		}
+		fullMemos, memoErr := s.moderateTransferMemos(ctx)
+		if memoErr != nil {
+			s.l.ErrorContext(ctx, "Failed to moderate transfer memos.",
+				ipcoin.LogErr, memoErr,
+			)
+		}
+		switch {
+		case commentErr != nil || memoErr != nil:
+			failures++
+			wait = min(moderationRetryBackoff<<min(failures-1, 16), moderationMaxBackoff)
+		case fullComments || fullMemos:
+			failures = 0
+			wait = 0
+		default:
+			failures = 0
+		}
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			unmoderated, err := storage.ReadCommentUnmoderated(ctx, s.pool)
-			if err != nil {
-				s.l.ErrorContext(ctx, "Failed to read unmoderated comments.",
-					ipcoin.LogErr, err,
-				)
-				continue
+		case <-time.After(wait):
+		}
+	}
+}
+
+// moderateComments claims one batch of comments, moderates it outside of a transaction, and records the verdicts and the
+// attempt in a second transaction. It reports whether the batch was full.
+func (s *server) moderateComments(ctx context.Context) (full bool, err error) {
+	now := s.clock.Now()
+	unmoderated, err := storage.ClaimCommentUnmoderated(ctx, s.pool, now, moderationLease, moderationBatchSize)
+	if err != nil {
+		return false, err
+	}
+	if len(unmoderated) == 0 {
+		return false, nil
+	}
+	s.l.InfoContext(ctx, "Claimed unmoderated comments.",
+		"count", len(unmoderated),
+	)
+
+	verdicts, moderateErr := s.moderateBatch(ctx, unmoderated)
+	commentIDs := make([]uuid.UUID, len(unmoderated))
+	for i, c := range unmoderated {
+		commentIDs[i] = c.ID
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	err = storage.CreateCommentModerationAttempt(ctx, tx, commentIDs, moderationAttempt(now, moderateErr))
+	if err != nil {
+		return false, err
+	}
+	if moderateErr == nil {
+		moderations := make([]storage.CommentModeration, len(verdicts))
+		for i, v := range verdicts {
+			moderations[i] = storage.CommentModeration{
+				CommentID:  unmoderated[i].ID,
+				Censored:   v.Censored,
+				Note:       v.Note,
+				Categories: v.Categories,
+				Revision:   unmoderated[i].Revision,
 			}
-			if len(unmoderated) == 0 {
-				continue
+		}
+		err = storage.CreateCommentModeration(ctx, tx, moderations, now)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to commit comment moderation: %w", err)
+	}
+	if moderateErr != nil {
+		return false, moderateErr
+	}
+	return len(unmoderated) == moderationBatchSize, nil
+}
+
+// moderateTransferMemos moderates memos as comments from the sender so every Moderator handles both. It behaves like
+// moderateComments.
+func (s *server) moderateTransferMemos(ctx context.Context) (full bool, err error) {
+	now := s.clock.Now()
+	unmoderated, err := storage.ClaimTransferMemoUnmoderated(ctx, s.pool, now, moderationLease, moderationBatchSize)
+	if err != nil {
+		return false, err
+	}
+	if len(unmoderated) == 0 {
+		return false, nil
+	}
+	s.l.InfoContext(ctx, "Claimed unmoderated transfer memos.",
+		"count", len(unmoderated),
+	)
+
+	memos := make([]storage.Comment, len(unmoderated))
+	transferIDs := make([]uuid.UUID, len(unmoderated))
+	for i, t := range unmoderated {
+		memos[i] = storage.Comment{
+			Created: t.Created,
+			ID:      t.ID,
+			Address: t.Sender,
+			Message: t.Memo,
+		}
+		transferIDs[i] = t.ID
+	}
+	verdicts, moderateErr := s.moderateBatch(ctx, memos)
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	err = storage.CreateTransferModerationAttempt(ctx, tx, transferIDs, moderationAttempt(now, moderateErr))
+	if err != nil {
+		return false, err
+	}
+	if moderateErr == nil {
+		moderations := make([]storage.TransferModeration, len(verdicts))
+		for i, v := range verdicts {
+			moderations[i] = storage.TransferModeration{
+				TransferID: unmoderated[i].ID,
+				Censored:   v.Censored,
+				Note:       v.Note,
 			}
-			s.l.InfoContext(ctx, "Read unmoderated comments.",
-				"count", len(unmoderated),
+		}
+		err = storage.CreateTransferModeration(ctx, tx, moderations, now)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to commit transfer moderation: %w", err)
+	}
+	if moderateErr != nil {
+		return false, moderateErr
+	}
+	return len(unmoderated) == moderationBatchSize, nil
+}
+
+func (s *server) moderateBatch(ctx context.Context, comments []storage.Comment) ([]ModerationVerdict, error) {
+	verdicts, err := s.moderator.Moderate(ctx, comments)
+	if err != nil {
+		return nil, err
+	}
+	if len(verdicts) != len(comments) {
+		return nil, fmt.Errorf("%w: got %d verdicts for %d comments", ErrModerationVerdictCount, len(verdicts), len(comments))
+	}
+	return verdicts, nil
+}
+
+func moderationAttempt(now time.Time, err error) storage.ModerationAttempt {
+	attempt := storage.ModerationAttempt{
+		Now:        now,
+		Backoff:    moderationInterval,
+		MaxBackoff: moderationMaxBackoff,
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
+
+// checkpointBalances periodically checkpoints balance diffs so historical balance reads only sum recent transfers.
+func (s *server) checkpointBalances(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(balanceCheckpointInterval):
+		}
+		count, err := s.createBalanceCheckpoints(ctx)
+		if err != nil {
+			s.l.ErrorContext(ctx, "Failed to create balance checkpoints.",
+				ipcoin.LogErr, err,
 			)
-			openaiCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-			response, err := s.openai.Moderations.New(openaiCtx, openai.ModerationNewParams{
-				Input: openai.ModerationNewParamsInputUnion{
-					OfStringArray: slices.Collect(func(yield func(string) bool) {
-						for _, c := range unmoderated {
-							if !yield(c.Message) {
-								return
-							}
-						}
-					}),
-				},
-				Model: openai.ModerationModelOmniModerationLatest,
-			})
-			cancel()
-			if err != nil {
-				s.l.ErrorContext(ctx, "Failed to get OpenAI moderation response.",
-					ipcoin.LogErr, err,
-				)
-				continue
-			}
-			moderations := make([]storage.CommentModeration, 0)
-			for i, result := range response.Results {
-				if i >= len(unmoderated) {
-					s.l.ErrorContext(ctx, "OpenAI moderation response has more results than unmoderated comments.",
-						"unmoderated_count", len(unmoderated),
-						"results_count", len(response.Results),
-					)
-					break
-				}
-				m := storage.CommentModeration{
-					CommentID: unmoderated[i].ID,
-					Censored:  result.Flagged,
-					Note:      "Moderated by OpenAI.",
-				}
-				moderations = append(moderations, m)
-			}
-			// TODO Use DB transaction?
-			err = storage.CreateCommentModeration(ctx, s.pool, moderations, s.clock.Now())
-			if err != nil {
-				s.l.ErrorContext(ctx, "Failed to create comment moderations.",
-					ipcoin.LogErr, err,
-				)
-				continue
-			}
+			continue
 		}
+		s.l.InfoContext(ctx, "Created balance checkpoints.",
+			"count", count,
+		)
+	}
+}
+
+// createBalanceCheckpoints reads the committed transfer sequence in its own transaction so transfers are only blocked
+// while it waits for in-flight transfers, then checkpoints up to it in a second transaction.
+func (s *server) createBalanceCheckpoints(ctx context.Context) (int64, error) {
+	seq, err := s.readTransferSeqCommitted(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	count, err := storage.CreateBalanceCheckpoints(ctx, tx, s.clock.Now(), seq)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to commit balance checkpoints: %w", err)
+	}
+	return count, nil
+}
+
+func (s *server) readTransferSeqCommitted(ctx context.Context) (int64, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	seq, err := storage.ReadTransferSeqCommitted(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to commit transfer sequence read: %w", err)
 	}
+	return seq, nil
 }
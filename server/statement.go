@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+const (
+	// statementPageSize is the number of entries read in each short transaction while streaming a statement.
+	statementPageSize = 500
+	// statementTimeout bounds how long one statement can be streamed.
+	statementTimeout = 10 * time.Minute
+)
+
+// GetStatement reads the statement one page per transaction and sends each page after its transaction ends, so a slow
+// client never holds a database connection or snapshot.
+func (s *server) GetStatement(request *proto.GetStatementRequest, stream grpc.ServerStreamingServer[proto.GetStatementResponse]) error {
+	ctx := stream.Context()
+	from, err := s.getPeer(ctx)
+	if err != nil {
+		return err
+	}
+	err = s.readLimiter.Wait(ctx, from)
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	address := from
+	if request.GetAddress() != nil {
+		var ok bool
+		address, ok = netip.AddrFromSlice(request.GetAddress())
+		if !ok {
+			return status.Error(codes.InvalidArgument, "invalid address")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, statementTimeout)
+	defer cancel()
+	dbReq := storage.ReadStatementRequest{
+		Address: address,
+		Limit:   statementPageSize,
+	}
+	for {
+		entries, next, err := s.readStatementPage(ctx, dbReq)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			direction := proto.StatementDirection_STATEMENT_DIRECTION_RECEIVED
+			if entry.Direction == storage.StatementDirectionSent {
+				direction = proto.StatementDirection_STATEMENT_DIRECTION_SENT
+			}
+			err = stream.Send(&proto.GetStatementResponse{
+				Entry: &proto.StatementEntry{
+					Created:             timestamppb.New(entry.Created),
+					TransferId:          entry.TransferID.String(),
+					Direction:           direction,
+					CounterpartyAddress: entry.Counterparty.AsSlice(),
+					Amount:              entry.Amount,
+					Balance:             entry.Balance,
+					Memo:                entry.Memo,
+					MemoCensored:        entry.MemoCensored,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return status.Error(codes.DeadlineExceeded, "statement took too long to stream")
+		}
+		dbReq.After = next
+	}
+}
+
+func (s *server) readStatementPage(ctx context.Context, request storage.ReadStatementRequest) ([]storage.StatementEntry, *storage.StatementCursor, error) {
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+	entries, next, err := storage.ReadStatement(ctx, tx, request)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return nil, nil, status.Error(codes.DeadlineExceeded, "statement took too long to stream")
+	case err != nil:
+		return nil, nil, status.Error(codes.Internal, "unable to read statement")
+	}
+	return entries, next, nil
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+func (s *server) GetThread(ctx context.Context, request *proto.GetThreadRequest) (*proto.GetThreadResponse, error) {
+	from, err := s.getPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.readLimiter.Wait(ctx, from)
+	if err != nil {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	var storageRequest storage.ReadThreadRequest
+	switch root := request.GetRoot().(type) {
+	case *proto.GetThreadRequest_CommentId:
+		id, err := uuid.Parse(root.CommentId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid comment ID")
+		}
+		storageRequest.CommentID = &id
+	case *proto.GetThreadRequest_TransferId:
+		id, err := uuid.Parse(root.TransferId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid transfer ID")
+		}
+		storageRequest.TransferID = &id
+	default:
+		return nil, status.Error(codes.InvalidArgument, "thread root must be a comment or a transfer")
+	}
+
+	tx, err := s.tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	response := &proto.GetThreadResponse{}
+	commentIDs := make([]uuid.UUID, 0)
+	var rootComment storage.Comment
+	if storageRequest.CommentID != nil {
+		rootComment, err = storage.ReadComment(ctx, tx, *storageRequest.CommentID)
+		if err != nil {
+			if errors.Is(err, storage.ErrCommentNotFound) {
+				return nil, status.Error(codes.NotFound, "comment not found")
+			}
+			return nil, status.Error(codes.Internal, "unable to read comment")
+		}
+		commentIDs = append(commentIDs, rootComment.ID)
+	} else {
+		transfer, err := storage.ReadTransfer(ctx, tx, *storageRequest.TransferID)
+		if err != nil {
+			if errors.Is(err, storage.ErrTransferNotFound) {
+				return nil, status.Error(codes.NotFound, "transfer not found")
+			}
+			return nil, status.Error(codes.Internal, "unable to read transfer")
+		}
+		memoCensored := false
+		if transfer.Memo != "" {
+			censored, err := storage.ReadTransferMemoCensored(ctx, tx, []uuid.UUID{transfer.ID})
+			if err != nil {
+				return nil, status.Error(codes.Internal, "unable to read transfer moderation")
+			}
+			memoCensored = slices.Contains(censored, transfer.ID)
+		}
+		response.Transfer = protoBuildTransfer(transfer, memoCensored)
+	}
+
+	replies, err := storage.ReadThread(ctx, tx, storageRequest)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to read thread")
+	}
+	for _, c := range replies {
+		commentIDs = append(commentIDs, c.ID)
+	}
+	moderations, err := storage.ReadCommentModerationLatest(ctx, tx, commentIDs)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to read comment moderation")
+	}
+	protoComment := func(c storage.Comment) *proto.Comment {
+		m, ok := moderations[c.ID]
+		if !ok {
+			return protoBuildComment(c, nil)
+		}
+		return protoBuildComment(c, &m)
+	}
+
+	if storageRequest.CommentID != nil {
+		response.Comment = protoComment(rootComment)
+	}
+	response.Replies = protoBuildThread(rootComment.ID, replies, protoComment)
+	return response, nil
+}
+
+// protoBuildThread nests replies under their parent comment, keeping their order. Direct replies to the root have a
+// parent transfer or the root comment as their parent. Replies whose parent was cut off by the thread limit are dropped.
+func protoBuildThread(rootCommentID uuid.UUID, replies []storage.Comment, protoComment func(c storage.Comment) *proto.Comment) []*proto.ThreadNode {
+	nodes := make(map[uuid.UUID]*proto.ThreadNode, len(replies))
+	for _, c := range replies {
+		nodes[c.ID] = &proto.ThreadNode{
+			Comment: protoComment(c),
+		}
+	}
+	roots := make([]*proto.ThreadNode, 0)
+	for _, c := range replies {
+		node := nodes[c.ID]
+		if c.ParentCommentID == nil || *c.ParentCommentID == rootCommentID {
+			roots = append(roots, node)
+			continue
+		}
+		parent, ok := nodes[*c.ParentCommentID]
+		if ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+	return roots
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/MicahParks/ipcoin/ctxkey"
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+func TestServer_GetThread(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	p := &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.4")},
+	}
+	ctx = context.WithValue(ctx, ctxkey.TestingPeer, p)
+	ctx, tx := addTx(ctx, t)
+	defer tx.Rollback(ctx)
+
+	createComment := func(request *proto.CreateCommentRequest) *proto.Comment {
+		response, err := s.CreateComment(ctx, request)
+		if err != nil {
+			t.Fatalf("Failed to create comment.\n  Error: %s", err)
+		}
+		return response.GetComment()
+	}
+	root := createComment(&proto.CreateCommentRequest{Comment: "root"})
+	reply := createComment(&proto.CreateCommentRequest{
+		Comment: "reply",
+		Parent:  &proto.CreateCommentRequest_ParentCommentId{ParentCommentId: root.GetId()},
+	})
+	nested := createComment(&proto.CreateCommentRequest{
+		Comment: "nested",
+		Parent:  &proto.CreateCommentRequest_ParentCommentId{ParentCommentId: reply.GetId()},
+	})
+	if nested.GetParentCommentId() != reply.GetId() {
+		t.Fatalf("Reply should reference its parent.")
+	}
+
+	nestedID := uuid.MustParse(nested.GetId())
+	moderations := []storage.CommentModeration{{
+		CommentID: nestedID,
+		Censored:  true,
+		Note:      "test",
+	}}
+	err := storage.CreateCommentModeration(ctx, tx, moderations, now)
+	if err != nil {
+		t.Fatalf("Failed to create comment moderation.\n  Error: %s", err)
+	}
+
+	response, err := s.GetThread(ctx, &proto.GetThreadRequest{
+		Root: &proto.GetThreadRequest_CommentId{CommentId: root.GetId()},
+	})
+	if err != nil {
+		t.Fatalf("Failed to get thread.\n  Error: %s", err)
+	}
+	if response.GetComment().GetId() != root.GetId() {
+		t.Fatalf("Thread should start at the root comment.")
+	}
+	replies := response.GetReplies()
+	if len(replies) != 1 || replies[0].GetComment().GetId() != reply.GetId() {
+		t.Fatalf("Thread should have one direct reply.")
+	}
+	nestedReplies := replies[0].GetReplies()
+	if len(nestedReplies) != 1 || nestedReplies[0].GetComment().GetId() != nested.GetId() {
+		t.Fatalf("Reply should have one nested reply.")
+	}
+	if !nestedReplies[0].GetComment().GetCensored() {
+		t.Fatalf("Nested reply should be censored.")
+	}
+
+	_, err = s.CreateComment(ctx, &proto.CreateCommentRequest{
+		Comment: "orphan",
+		Parent:  &proto.CreateCommentRequest_ParentTransferId{ParentTransferId: uuid.New().String()},
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Should have not found error.\n  Error: %s", err)
+	}
+}
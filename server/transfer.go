@@ -15,19 +15,25 @@ import (
 	"github.com/MicahParks/ipcoin/storage"
 )
 
+const (
+	maxIdempotencyKeyLength = 128
+	maxMemoLength           = 140
+	maxTransferBatchSize    = 100
+)
+
 func (s *server) CreateTransfer(ctx context.Context, request *proto.CreateTransferRequest) (*proto.CreateTransferResponse, error) {
 	amount := request.GetAmount()
 	if amount < 1 {
 		return nil, status.Error(codes.InvalidArgument, "invalid amount")
 	}
-	toBytes := request.GetRecipientAddress()
-	var recipient netip.Addr
-	switch len(toBytes) {
-	case 4:
-		recipient = netip.AddrFrom4([4]byte(toBytes))
-	case 16:
-		recipient = netip.AddrFrom16([16]byte(toBytes))
-	default:
+	if len(request.GetIdempotencyKey()) > maxIdempotencyKeyLength {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key must not be longer than %d characters", maxIdempotencyKeyLength)
+	}
+	if len(request.GetMemo()) > maxMemoLength {
+		return nil, status.Errorf(codes.InvalidArgument, "memo must not be longer than %d characters", maxMemoLength)
+	}
+	recipient, ok := recipientFromBytes(request.GetRecipientAddress())
+	if !ok {
 		return nil, status.Error(codes.InvalidArgument, "invalid to address")
 	}
 	sender, err := s.getPeer(ctx)
@@ -60,12 +66,21 @@ func (s *server) CreateTransfer(ctx context.Context, request *proto.CreateTransf
 			Sender:    sender,
 			Now:       now,
 			Recipient: recipient,
+			Memo:      request.GetMemo(),
+
+			IdempotencyKey: request.GetIdempotencyKey(),
 		}
 		storageResponse, innerErr = storage.CreateTransfer(ctx, tx, dbReq)
 		switch {
 		case errors.Is(innerErr, storage.ErrInsufficientBalance):
 			innerErr = status.Error(codes.InvalidArgument, "insufficient balance")
 			return
+		case errors.Is(innerErr, storage.ErrIdempotencyKeyReuse):
+			innerErr = status.Error(codes.InvalidArgument, "idempotency key was used for a different transfer")
+			return
+		case errors.Is(innerErr, storage.ErrIdempotencyKeyInUse):
+			innerErr = status.Error(codes.Aborted, "idempotency key is being used by a concurrent transfer")
+			return
 		case innerErr != nil:
 			innerErr = status.Error(codes.Internal, "unable to transfer to address")
 			return
@@ -81,20 +96,126 @@ func (s *server) CreateTransfer(ctx context.Context, request *proto.CreateTransf
 		return nil, innerErr
 	}
 
-	pbNow := timestamppb.New(storageResponse.Transfer.Created)
 	response := &proto.CreateTransferResponse{
-		Transfer: &proto.Transfer{
-			Created:          pbNow,
-			Id:               storageResponse.Transfer.ID.String(),
-			SenderAddress:    storageResponse.Transfer.Sender.AsSlice(),
-			RecipientAddress: storageResponse.Transfer.Recipient.AsSlice(),
-			Amount:           storageResponse.Transfer.Amount,
-		},
+		Transfer: protoBuildTransfer(storageResponse.Transfer, false),
 		SenderBalance: &proto.Balance{
-			Timestamp: pbNow,
+			Timestamp: timestamppb.New(storageResponse.Transfer.Created),
 			Address:   storageResponse.Transfer.Sender.AsSlice(),
 			Available: storageResponse.SenderBalance,
 		},
 	}
 	return response, nil
 }
+
+func protoBuildTransfer(t storage.Transfer, memoCensored bool) *proto.Transfer {
+	return &proto.Transfer{
+		Created:          timestamppb.New(t.Created),
+		Id:               t.ID.String(),
+		SenderAddress:    t.Sender.AsSlice(),
+		RecipientAddress: t.Recipient.AsSlice(),
+		Amount:           t.Amount,
+		Memo:             t.Memo,
+		MemoCensored:     memoCensored,
+	}
+}
+
+func (s *server) CreateTransferBatch(ctx context.Context, request *proto.CreateTransferBatchRequest) (*proto.CreateTransferBatchResponse, error) {
+	if len(request.GetTransfers()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "batch must not be empty")
+	}
+	if len(request.GetTransfers()) > maxTransferBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "batch must not have more than %d transfers", maxTransferBatchSize)
+	}
+	sender, err := s.getPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]storage.CreateTransferBatchEntry, len(request.GetTransfers()))
+	for i, t := range request.GetTransfers() {
+		if t.GetAmount() < 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid amount for transfer %d", i)
+		}
+		recipient, ok := recipientFromBytes(t.GetRecipientAddress())
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid to address for transfer %d", i)
+		}
+		if len(t.GetMemo()) > maxMemoLength {
+			return nil, status.Errorf(codes.InvalidArgument, "memo must not be longer than %d characters for transfer %d", maxMemoLength, i)
+		}
+		if sender == recipient {
+			return nil, status.Error(codes.InvalidArgument, "cannot transfer to self")
+		}
+		entries[i] = storage.CreateTransferBatchEntry{
+			Amount:    t.GetAmount(),
+			Recipient: recipient,
+			Memo:      t.GetMemo(),
+		}
+	}
+	err = s.writeLimiter.Wait(ctx, sender)
+	if err != nil {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	var innerErr error
+	var storageResponse storage.CreateTransferBatchResponse
+	var now time.Time
+	s.addrLocker.WithLock(ctx, sender, func() {
+		var tx pgx.Tx
+		tx, innerErr = s.tx(ctx)
+		if innerErr != nil {
+			innerErr = status.Error(codes.Internal, "")
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		now = s.clock.Now()
+		dbReq := storage.CreateTransferBatchRequest{
+			Entries: entries,
+			Sender:  sender,
+			Now:     now,
+		}
+		storageResponse, innerErr = storage.CreateTransferBatch(ctx, tx, dbReq)
+		switch {
+		case errors.Is(innerErr, storage.ErrInsufficientBalance):
+			innerErr = status.Error(codes.InvalidArgument, "insufficient balance")
+			return
+		case innerErr != nil:
+			innerErr = status.Error(codes.Internal, "unable to transfer to addresses")
+			return
+		}
+
+		innerErr = tx.Commit(ctx)
+		if innerErr != nil {
+			innerErr = status.Error(codes.Internal, "unable to commit database transaction")
+			return
+		}
+	})
+	if innerErr != nil {
+		return nil, innerErr
+	}
+
+	transfers := make([]*proto.Transfer, len(storageResponse.Transfers))
+	for i, t := range storageResponse.Transfers {
+		transfers[i] = protoBuildTransfer(t, false)
+	}
+	response := &proto.CreateTransferBatchResponse{
+		Transfers: transfers,
+		SenderBalance: &proto.Balance{
+			Timestamp: timestamppb.New(now),
+			Address:   sender.AsSlice(),
+			Available: storageResponse.SenderBalance,
+		},
+	}
+	return response, nil
+}
+
+func recipientFromBytes(b []byte) (netip.Addr, bool) {
+	switch len(b) {
+	case 4:
+		return netip.AddrFrom4([4]byte(b)), true
+	case 16:
+		return netip.AddrFrom16([16]byte(b)), true
+	default:
+		return netip.Addr{}, false
+	}
+}
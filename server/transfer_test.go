@@ -137,3 +137,44 @@ func TestServer_TransferInvalidArguments(t *testing.T) {
 		t.Fatalf("Should have invalid argument error.\n  Error: %s", err)
 	}
 }
+
+func TestServer_TransferBatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx, tx := addTx(ctx, t)
+	defer tx.Rollback(ctx)
+
+	p := &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.3")},
+	}
+	ctx = context.WithValue(ctx, ctxkey.TestingPeer, p)
+
+	request := &proto.CreateTransferBatchRequest{
+		Transfers: []*proto.CreateTransferBatchEntry{
+			{Amount: 1, RecipientAddress: netip.MustParseAddr("127.0.0.2").AsSlice()},
+			{Amount: 2, RecipientAddress: netip.MustParseAddr("::2").AsSlice()},
+		},
+	}
+	response, err := s.CreateTransferBatch(ctx, request)
+	if err != nil {
+		t.Fatalf("Failed to transfer batch.\n  Error: %s", err)
+	}
+	if len(response.GetTransfers()) != 2 {
+		t.Fatalf("Batch should have 2 transfers but has %d.", len(response.GetTransfers()))
+	}
+	expectedBalance := storage.BalanceUntouched(now) - 3
+	if response.GetSenderBalance().GetAvailable() != expectedBalance {
+		t.Fatalf("Unexpected sender balance.\n  Expected: %d\n  Actual: %d", expectedBalance, response.GetSenderBalance().GetAvailable())
+	}
+
+	request = &proto.CreateTransferBatchRequest{
+		Transfers: []*proto.CreateTransferBatchEntry{
+			{Amount: 1, RecipientAddress: netip.MustParseAddr("127.0.0.2").AsSlice()},
+			{Amount: 1, RecipientAddress: netip.MustParseAddr("127.0.0.3").AsSlice()},
+		},
+	}
+	_, err = s.CreateTransferBatch(ctx, request)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Should have invalid argument error for transfer to self.\n  Error: %s", err)
+	}
+}
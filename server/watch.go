@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/MicahParks/ipcoin"
+	"github.com/MicahParks/ipcoin/proto"
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+// feedSubscriberBuffer is how many events a WatchFeed stream may fall behind before it is closed.
+const feedSubscriberBuffer = 100
+
+func (s *server) WatchFeed(request *proto.WatchFeedRequest, stream grpc.ServerStreamingServer[proto.WatchFeedResponse]) error {
+	ctx := stream.Context()
+	from, err := s.getPeer(ctx)
+	if err != nil {
+		return err
+	}
+	err = s.readLimiter.Wait(ctx, from)
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	var address *netip.Addr
+	if request.GetAddress() != nil {
+		a, ok := netip.AddrFromSlice(request.GetAddress())
+		if !ok {
+			return status.Error(codes.InvalidArgument, "invalid address")
+		}
+		address = &a
+	}
+
+	events, unsubscribe := s.feedHub.subscribe()
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "feed watcher fell behind")
+			}
+			var response *proto.WatchFeedResponse
+			switch {
+			case event.Comment != nil:
+				if address != nil && event.Comment.Address != *address {
+					continue
+				}
+				response = &proto.WatchFeedResponse{
+					Event: &proto.WatchFeedResponse_Comment{
+						Comment: protoBuildComment(*event.Comment, nil),
+					},
+				}
+			case event.Transfer != nil:
+				if address != nil && event.Transfer.Sender != *address && event.Transfer.Recipient != *address {
+					continue
+				}
+				response = &proto.WatchFeedResponse{
+					Event: &proto.WatchFeedResponse_Transfer{
+						Transfer: protoBuildTransfer(*event.Transfer, false),
+					},
+				}
+			default:
+				continue
+			}
+			err = stream.Send(response)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// listenFeed relays committed comments and transfers from PostgreSQL to WatchFeed streams until the context is
+// canceled, reconnecting after failures.
+func (s *server) listenFeed(ctx context.Context) {
+	for {
+		err := storage.ListenFeed(ctx, s.pool, s.feedHub.publish)
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		s.l.ErrorContext(ctx, "Failed to listen for feed events.",
+			ipcoin.LogErr, err,
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+type feedHub struct {
+	mux  sync.Mutex
+	subs map[chan storage.FeedEvent]struct{}
+}
+
+func newFeedHub() *feedHub {
+	return &feedHub{
+		subs: make(map[chan storage.FeedEvent]struct{}),
+	}
+}
+
+func (h *feedHub) subscribe() (<-chan storage.FeedEvent, func()) {
+	ch := make(chan storage.FeedEvent, feedSubscriberBuffer)
+	h.mux.Lock()
+	h.subs[ch] = struct{}{}
+	h.mux.Unlock()
+	return ch, func() {
+		h.mux.Lock()
+		defer h.mux.Unlock()
+		_, ok := h.subs[ch]
+		if ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish never blocks. Subscribers that have fallen behind are closed so their clients can reconnect instead of
+// silently missing events.
+func (h *feedHub) publish(event storage.FeedEvent) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
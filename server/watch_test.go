@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/MicahParks/ipcoin/storage"
+)
+
+func TestFeedHub_Publish(t *testing.T) {
+	hub := newFeedHub()
+	events, unsubscribe := hub.subscribe()
+	defer unsubscribe()
+
+	comment := storage.Comment{
+		Address: netip.MustParseAddr("192.168.0.1"),
+		Message: "test",
+	}
+	hub.publish(storage.FeedEvent{Comment: &comment})
+	event := <-events
+	if event.Comment == nil || event.Comment.Message != comment.Message {
+		t.Fatalf("Subscriber should receive the published comment.")
+	}
+}
+
+func TestFeedHub_SlowSubscriberClosed(t *testing.T) {
+	hub := newFeedHub()
+	events, unsubscribe := hub.subscribe()
+	defer unsubscribe()
+
+	for range feedSubscriberBuffer + 1 {
+		hub.publish(storage.FeedEvent{Transfer: &storage.Transfer{Amount: 1}})
+	}
+	count := 0
+	for range events {
+		count++
+	}
+	if count != feedSubscriberBuffer {
+		t.Fatalf("Slow subscriber should receive %d buffered events before closing but received %d.", feedSubscriberBuffer, count)
+	}
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AddressStats is the running total of everything an address has done. It is updated in the same transaction as each
+// comment and transfer.
+type AddressStats struct {
+	Address       netip.Addr `db:"address"`
+	BalanceDiff   int64      `db:"balance_diff"`
+	CommentCount  int64      `db:"comment_count"`
+	TransferCount int64      `db:"transfer_count"`
+}
+
+// AddressStatsDrift is an address whose stored stats do not match its comments and transfers.
+type AddressStatsDrift struct {
+	Expected AddressStats
+	Actual   AddressStats
+}
+
+// addressStatsExpectedQuery recomputes address_stats from the comment and transfer tables. A transfer to oneself counts
+// as two transfers, once for each side.
+const addressStatsExpectedQuery = `
+WITH comments AS (SELECT address, COUNT(*) AS comment_count
+                  FROM comment
+                  GROUP BY address),
+     transfers AS (SELECT address,
+                          COUNT(*)    AS transfer_count,
+                          SUM(amount) AS balance_diff
+                   FROM (SELECT recipient AS address, amount
+                         FROM transfer
+                         UNION ALL
+                         SELECT sender AS address, -amount
+                         FROM transfer) AS flat
+                   GROUP BY address)
+SELECT COALESCE(t.address, c.address)        AS address,
+       COALESCE(t.balance_diff, 0)::BIGINT   AS balance_diff,
+       COALESCE(c.comment_count, 0)::BIGINT  AS comment_count,
+       COALESCE(t.transfer_count, 0)::BIGINT AS transfer_count
+FROM transfers t
+         FULL OUTER JOIN comments c ON t.address = c.address
+`
+
+// queueAddressStats adds the deltas to address_stats. Deltas for the same address are merged and rows are updated in
+// address order so concurrent transactions touching the same addresses cannot deadlock.
+func queueAddressStats(batch *pgx.Batch, deltas ...AddressStats) {
+	merged := make(map[netip.Addr]AddressStats, len(deltas))
+	for _, d := range deltas {
+		m := merged[d.Address]
+		m.Address = d.Address
+		m.BalanceDiff += d.BalanceDiff
+		m.CommentCount += d.CommentCount
+		m.TransferCount += d.TransferCount
+		merged[d.Address] = m
+	}
+	addresses := make([]netip.Addr, 0, len(merged))
+	for a := range merged {
+		addresses = append(addresses, a)
+	}
+	slices.SortFunc(addresses, netip.Addr.Compare)
+
+	//language=sql
+	query := `
+INSERT INTO address_stats (address, balance_diff, comment_count, transfer_count)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (address) DO UPDATE SET balance_diff   = address_stats.balance_diff + excluded.balance_diff,
+                                    comment_count  = address_stats.comment_count + excluded.comment_count,
+                                    transfer_count = address_stats.transfer_count + excluded.transfer_count
+`
+	for _, a := range addresses {
+		m := merged[a]
+		batch.Queue(query, m.Address, m.BalanceDiff, m.CommentCount, m.TransferCount)
+	}
+}
+
+func updateAddressStats(ctx context.Context, db dbConn, deltas ...AddressStats) error {
+	batch := &pgx.Batch{}
+	queueAddressStats(batch, deltas...)
+	err := db.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return fmt.Errorf("failed to update address stats: %w", err)
+	}
+	return nil
+}
+
+// transferAddressStats is the change a transfer makes to the stats of its sender and recipient.
+func transferAddressStats(t Transfer) []AddressStats {
+	return []AddressStats{
+		{Address: t.Sender, BalanceDiff: -t.Amount, TransferCount: 1},
+		{Address: t.Recipient, BalanceDiff: t.Amount, TransferCount: 1},
+	}
+}
+
+// ReadAddressStats returns zero stats for an address that has never commented or transferred.
+func ReadAddressStats(ctx context.Context, db dbConn, address netip.Addr) (AddressStats, error) {
+	//language=sql
+	query := `
+SELECT address, balance_diff, comment_count, transfer_count
+FROM address_stats
+WHERE address = $1
+`
+	rows, err := db.Query(ctx, query, address)
+	if err != nil {
+		return AddressStats{}, fmt.Errorf("failed to read address stats: %w", err)
+	}
+	stats, err := pgx.CollectRows(rows, pgx.RowToStructByName[AddressStats])
+	if err != nil {
+		return AddressStats{}, fmt.Errorf("failed to collect address stats: %w", err)
+	}
+	if len(stats) == 0 {
+		return AddressStats{Address: address}, nil
+	}
+	return stats[0], nil
+}
+
+// VerifyAddressStats recomputes address_stats from the comment and transfer tables and returns every address that
+// differs. An address missing from either side counts as all zeros.
+func VerifyAddressStats(ctx context.Context, db dbConn) ([]AddressStatsDrift, error) {
+	//language=sql
+	query := `
+WITH expected AS (` + addressStatsExpectedQuery + `)
+SELECT COALESCE(e.address, s.address),
+       COALESCE(e.balance_diff, 0),
+       COALESCE(e.comment_count, 0),
+       COALESCE(e.transfer_count, 0),
+       COALESCE(s.balance_diff, 0),
+       COALESCE(s.comment_count, 0),
+       COALESCE(s.transfer_count, 0)
+FROM expected e
+         FULL OUTER JOIN address_stats s ON s.address = e.address
+WHERE (COALESCE(e.balance_diff, 0), COALESCE(e.comment_count, 0), COALESCE(e.transfer_count, 0))
+          IS DISTINCT FROM (COALESCE(s.balance_diff, 0), COALESCE(s.comment_count, 0), COALESCE(s.transfer_count, 0))
+ORDER BY 1
+`
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify address stats: %w", err)
+	}
+	defer rows.Close()
+	drifts := make([]AddressStatsDrift, 0)
+	for rows.Next() {
+		var d AddressStatsDrift
+		e, a := &d.Expected, &d.Actual
+		err = rows.Scan(&e.Address, &e.BalanceDiff, &e.CommentCount, &e.TransferCount, &a.BalanceDiff, &a.CommentCount, &a.TransferCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address stats drift: %w", err)
+		}
+		a.Address = e.Address
+		drifts = append(drifts, d)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate over address stats drift rows: %w", err)
+	}
+	return drifts, nil
+}
+
+// BackfillAddressStats overwrites every drifted address with the stats recomputed from the comment and transfer tables
+// and returns the drift it repaired. Comments and transfers are blocked until the transaction ends so none are missed.
+func BackfillAddressStats(ctx context.Context, tx pgx.Tx) ([]AddressStatsDrift, error) {
+	_, err := tx.Exec(ctx, "LOCK TABLE comment, transfer IN SHARE MODE")
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock comment and transfer tables: %w", err)
+	}
+	drifts, err := VerifyAddressStats(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	//language=sql
+	upsert := `
+INSERT INTO address_stats (address, balance_diff, comment_count, transfer_count)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (address) DO UPDATE SET balance_diff   = excluded.balance_diff,
+                                    comment_count  = excluded.comment_count,
+                                    transfer_count = excluded.transfer_count
+`
+	//language=sql
+	remove := `
+DELETE
+FROM address_stats
+WHERE address = $1
+`
+	batch := &pgx.Batch{}
+	for _, d := range drifts {
+		e := d.Expected
+		if e.BalanceDiff == 0 && e.CommentCount == 0 && e.TransferCount == 0 {
+			batch.Queue(remove, e.Address)
+			continue
+		}
+		batch.Queue(upsert, e.Address, e.BalanceDiff, e.CommentCount, e.TransferCount)
+	}
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to backfill address stats: %w", err)
+	}
+	return drifts, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAddressStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sender := netip.MustParseAddr("192.168.17.1")
+	recipient := netip.MustParseAddr("192.168.17.2")
+	_, err = CreateTransfer(ctx, tx, CreateTransferRequest{
+		Amount:    5,
+		Sender:    sender,
+		Now:       now,
+		Recipient: recipient,
+	})
+	if err != nil {
+		t.Fatalf("Failed to transfer.\n  Error: %s", err)
+	}
+	_, err = CreateTransferBatch(ctx, tx, CreateTransferBatchRequest{
+		Entries: []CreateTransferBatchEntry{
+			{Amount: 2, Recipient: recipient},
+			{Amount: 3, Recipient: recipient},
+		},
+		Sender: sender,
+		Now:    now,
+	})
+	if err != nil {
+		t.Fatalf("Failed to transfer batch.\n  Error: %s", err)
+	}
+	_, err = CreateComment(ctx, tx, CreateCommentRequest{
+		Addr:    sender,
+		Message: "test",
+		Now:     now,
+	})
+	if err != nil {
+		t.Fatalf("Failed to write comment.\n  Error: %s", err)
+	}
+
+	expected := AddressStats{
+		Address:       sender,
+		BalanceDiff:   -10,
+		CommentCount:  1,
+		TransferCount: 3,
+	}
+	stats, err := ReadAddressStats(ctx, tx, sender)
+	if err != nil {
+		t.Fatalf("Failed to read address stats.\n  Error: %s", err)
+	}
+	if stats != expected {
+		t.Fatalf("Address stats incorrect.\n  Expected: %+v\n  Actual: %+v", expected, stats)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE address_stats SET balance_diff = 0 WHERE address = $1", sender)
+	if err != nil {
+		t.Fatalf("Failed to corrupt address stats.\n  Error: %s", err)
+	}
+	drifted := func(d AddressStatsDrift) bool {
+		return d.Expected.Address == sender
+	}
+	drifts, err := VerifyAddressStats(ctx, tx)
+	if err != nil {
+		t.Fatalf("Failed to verify address stats.\n  Error: %s", err)
+	}
+	i := slices.IndexFunc(drifts, drifted)
+	if i == -1 {
+		t.Fatalf("Drift should be reported for %s.", sender)
+	}
+	if drifts[i].Expected != expected || drifts[i].Actual.BalanceDiff != 0 {
+		t.Fatalf("Drift incorrect.\n  Drift: %+v", drifts[i])
+	}
+
+	_, err = BackfillAddressStats(ctx, tx)
+	if err != nil {
+		t.Fatalf("Failed to backfill address stats.\n  Error: %s", err)
+	}
+	drifts, err = VerifyAddressStats(ctx, tx)
+	if err != nil {
+		t.Fatalf("Failed to verify address stats.\n  Error: %s", err)
+	}
+	if len(drifts) != 0 {
+		t.Fatalf("Backfill should repair all drift.\n  Drift: %+v", drifts)
+	}
+}
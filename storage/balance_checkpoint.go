@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// balanceCheckpointLockKey is the PostgreSQL advisory lock key held while creating or repairing balance checkpoints so
+// replicas do not checkpoint overlapping intervals.
+const balanceCheckpointLockKey = migrationLockKey + 1
+
+// transferSeqLockTimeout bounds how long ReadTransferSeqCommitted waits for in-flight transfers. New transfers queue
+// behind the wait, so a run gives up instead of stalling them behind a slow transfer.
+const transferSeqLockTimeout = "5s"
+
+// BalanceCheckpointDrift is a checkpoint that does not match the sum of the transfers it covers.
+type BalanceCheckpointDrift struct {
+	Address  netip.Addr
+	AsOf     time.Time
+	Expected int64
+	Actual   int64
+}
+
+// balanceCheckpointExpected sums the transfers a checkpoint covers, which are the transfers created at or before its
+// as_of with a sequence at or below its seq. It expects the checkpoint as b and its transfers joined as t.
+const balanceCheckpointExpected = `
+COALESCE(SUM(CASE WHEN t.recipient = b.address THEN t.amount ELSE 0 END) -
+         SUM(CASE WHEN t.sender = b.address THEN t.amount ELSE 0 END), 0)::BIGINT`
+
+// ReadTransferSeqCommitted waits for every transfer that is being written to commit or roll back and returns the
+// largest transfer sequence. Every transfer with a sequence at or below it is committed and every later transfer gets a
+// larger one. New transfers are blocked until the transaction ends, so callers commit right after reading it.
+func ReadTransferSeqCommitted(ctx context.Context, tx pgx.Tx) (int64, error) {
+	_, err := tx.Exec(ctx, "SET LOCAL lock_timeout = '"+transferSeqLockTimeout+"'")
+	if err != nil {
+		return 0, fmt.Errorf("failed to set transfer table lock timeout: %w", err)
+	}
+	_, err = tx.Exec(ctx, "LOCK TABLE transfer IN SHARE MODE")
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock transfer table: %w", err)
+	}
+	var seq int64
+	err = tx.QueryRow(ctx, "SELECT COALESCE(MAX(seq), 0) FROM transfer").Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read latest transfer sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// CreateBalanceCheckpoints checkpoints every address with a transfer that is created at or before asOf, has a sequence
+// at or below seq, and was not covered by the previous run. It returns how many checkpoints it created. seq must come
+// from ReadTransferSeqCommitted in an earlier, committed transaction so every transfer it covers is visible. A transfer
+// committed later, even if it is dated before asOf, has a larger sequence and is summed after the checkpoint instead.
+// Nothing is created if asOf or seq is behind the previous run.
+func CreateBalanceCheckpoints(ctx context.Context, tx pgx.Tx, asOf time.Time, seq int64) (int64, error) {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", int64(balanceCheckpointLockKey))
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire balance checkpoint lock: %w", err)
+	}
+
+	var previousAsOf *time.Time
+	var previousSeq *int64
+	//language=sql
+	query := `
+SELECT as_of, seq
+FROM balance_checkpoint
+ORDER BY as_of DESC, seq DESC
+LIMIT 1
+`
+	err = tx.QueryRow(ctx, query).Scan(&previousAsOf, &previousSeq)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("failed to read previous balance checkpoint: %w", err)
+	}
+	if previousAsOf != nil && (!asOf.After(*previousAsOf) || seq < *previousSeq) {
+		return 0, nil
+	}
+
+	// Every run covers the transfers the previous run did not, so the latest checkpoint of an address plus the
+	// transfers in this run is its balance diff as of this run. Addresses without transfers in this run keep their
+	// older checkpoint. The transfers not yet covered are the ones with a newer sequence, read from the sequence index,
+	// and the older ones dated after the previous run, read from the created index. Neither scans the whole table.
+	//language=sql
+	query = `
+WITH uncovered AS (SELECT sender, recipient, amount
+                   FROM transfer
+                   WHERE seq > COALESCE($3::BIGINT, 0)
+                     AND seq <= $4
+                     AND created <= $2
+                   UNION ALL
+                   SELECT sender, recipient, amount
+                   FROM transfer
+                   WHERE created > COALESCE($1::TIMESTAMPTZ, '-infinity')
+                     AND created <= $2
+                     AND seq <= COALESCE($3::BIGINT, 0))
+INSERT
+INTO balance_checkpoint (address, as_of, seq, balance_diff)
+SELECT changed.address, $2, $4, COALESCE(latest.balance_diff, 0) + changed.diff
+FROM (SELECT address, SUM(amount) AS diff
+      FROM (SELECT recipient AS address, amount
+            FROM uncovered
+            UNION ALL
+            SELECT sender AS address, -amount
+            FROM uncovered) AS flat
+      GROUP BY address) AS changed
+         LEFT JOIN LATERAL (SELECT balance_diff
+                            FROM balance_checkpoint b
+                            WHERE b.address = changed.address
+                            ORDER BY b.as_of DESC
+                            LIMIT 1) latest ON TRUE
+`
+	tag, err := tx.Exec(ctx, query, previousAsOf, asOf, previousSeq, seq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create balance checkpoints: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// queueBalanceDiffAsOf reads the balance diff of an address from transfers created at or before asOf. Only the
+// transfers the latest checkpoint does not cover are summed.
+func queueBalanceDiffAsOf(batch *pgx.Batch, address netip.Addr, asOf time.Time, balanceDiff *int64) {
+	//language=sql
+	query := `
+WITH checkpoint AS (SELECT as_of, seq, balance_diff
+                    FROM balance_checkpoint
+                    WHERE address = $1
+                      AND as_of <= $2
+                    ORDER BY as_of DESC
+                    LIMIT 1)
+SELECT COALESCE((SELECT balance_diff FROM checkpoint), 0) +
+       COALESCE((SELECT SUM(CASE WHEN recipient = $1 THEN amount ELSE 0 END) -
+                        SUM(CASE WHEN sender = $1 THEN amount ELSE 0 END)
+                 FROM transfer
+                 WHERE (sender = $1 OR recipient = $1)
+                   AND created <= $2
+                   AND NOT (created <= COALESCE((SELECT as_of FROM checkpoint), '-infinity') AND
+                            seq <= COALESCE((SELECT seq FROM checkpoint), 0))), 0)
+`
+	batch.Queue(query, address, asOf).QueryRow(func(row pgx.Row) error {
+		err := row.Scan(balanceDiff)
+		if err != nil {
+			return fmt.Errorf("failed to read balance diff from checkpoint: %w", err)
+		}
+		return nil
+	})
+}
+
+// VerifyBalanceCheckpoints compares every checkpoint to the full sum of the transfers it covers and returns the ones
+// that differ. A balance read from a matching checkpoint plus the transfers it does not cover equals the full sum.
+func VerifyBalanceCheckpoints(ctx context.Context, db dbConn) ([]BalanceCheckpointDrift, error) {
+	//language=sql
+	query := `
+SELECT b.address, b.as_of, ` + balanceCheckpointExpected + `, b.balance_diff
+FROM balance_checkpoint b
+         LEFT JOIN transfer t ON (t.sender = b.address OR t.recipient = b.address)
+    AND t.created <= b.as_of
+    AND t.seq <= b.seq
+GROUP BY b.address, b.as_of, b.balance_diff
+HAVING b.balance_diff <> ` + balanceCheckpointExpected + `
+ORDER BY b.address, b.as_of
+`
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify balance checkpoints: %w", err)
+	}
+	defer rows.Close()
+	drifts := make([]BalanceCheckpointDrift, 0)
+	for rows.Next() {
+		var d BalanceCheckpointDrift
+		err = rows.Scan(&d.Address, &d.AsOf, &d.Expected, &d.Actual)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance checkpoint drift: %w", err)
+		}
+		drifts = append(drifts, d)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate over balance checkpoint drift rows: %w", err)
+	}
+	return drifts, nil
+}
+
+// RepairBalanceCheckpoints overwrites every drifted checkpoint with the sum of the transfers it covers and returns the
+// drift it repaired. Later checkpoints are built on the latest one, so repairing them all stops the drift from
+// spreading.
+func RepairBalanceCheckpoints(ctx context.Context, tx pgx.Tx) ([]BalanceCheckpointDrift, error) {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", int64(balanceCheckpointLockKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire balance checkpoint lock: %w", err)
+	}
+	drifts, err := VerifyBalanceCheckpoints(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	//language=sql
+	query := `
+UPDATE balance_checkpoint
+SET balance_diff = $3
+WHERE address = $1
+  AND as_of = $2
+`
+	batch := &pgx.Batch{}
+	for _, d := range drifts {
+		batch.Queue(query, d.Address, d.AsOf, d.Expected)
+	}
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to repair balance checkpoints: %w", err)
+	}
+	return drifts, nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBalanceCheckpoint(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ipA := netip.MustParseAddr("192.168.18.1")
+	ipB := netip.MustParseAddr("192.168.18.2")
+	start := now.Add(-4 * time.Hour)
+	transfer := func(amount int64, sender, recipient netip.Addr, created time.Time) {
+		_, err := CreateTransfer(ctx, tx, CreateTransferRequest{
+			Amount:    amount,
+			Sender:    sender,
+			Now:       created,
+			Recipient: recipient,
+		})
+		if err != nil {
+			t.Fatalf("Failed to transfer.\n  Error: %s", err)
+		}
+	}
+	checkpoint := func(asOf time.Time) {
+		seq, err := ReadTransferSeqCommitted(ctx, tx)
+		if err != nil {
+			t.Fatalf("Failed to read committed transfer sequence.\n  Error: %s", err)
+		}
+		_, err = CreateBalanceCheckpoints(ctx, tx, asOf, seq)
+		if err != nil {
+			t.Fatalf("Failed to create balance checkpoints.\n  Error: %s", err)
+		}
+	}
+	verify := func(expectedDiffs []int64) {
+		points, err := GetBalanceHistory(ctx, tx, GetBalanceHistoryRequest{
+			Address:  ipA,
+			Start:    start,
+			End:      start.Add(3 * time.Hour),
+			Interval: time.Hour,
+		})
+		if err != nil {
+			t.Fatalf("Failed to read balance history.\n  Error: %s", err)
+		}
+		for i, p := range points {
+			expectedBalance := BalanceUntouched(p.Timestamp) + expectedDiffs[i]
+			if p.Available != expectedBalance {
+				t.Fatalf("Balance history point %d has unexpected balance.\n  Expected: %d\n  Actual: %d", i, expectedBalance, p.Available)
+			}
+		}
+		drifts, err := VerifyBalanceCheckpoints(ctx, tx)
+		if err != nil {
+			t.Fatalf("Failed to verify balance checkpoints.\n  Error: %s", err)
+		}
+		if len(drifts) != 0 {
+			t.Fatalf("Checkpoints should match transfers.\n  Drift: %+v", drifts)
+		}
+	}
+
+	transfer(5, ipA, ipB, start.Add(30*time.Minute))
+	checkpoint(start.Add(time.Hour))
+	transfer(2, ipB, ipA, start.Add(90*time.Minute))
+	checkpoint(start.Add(2 * time.Hour))
+	transfer(1, ipA, ipB, start.Add(150*time.Minute))
+	verify([]int64{0, -5, -3, -4})
+
+	// A transfer dated before the existing checkpoints, like one that waited on a lock or was back-dated, must still be
+	// counted by every later balance read and checkpoint.
+	transfer(4, ipA, ipB, start.Add(45*time.Minute))
+	verify([]int64{0, -9, -7, -8})
+	checkpoint(start.Add(3 * time.Hour))
+	verify([]int64{0, -9, -7, -8})
+
+	var count int
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM balance_checkpoint WHERE address = $1", ipA).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to count balance checkpoints.\n  Error: %s", err)
+	}
+	if count != 3 {
+		t.Fatalf("Address should have 3 balance checkpoints but has %d.", count)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE balance_checkpoint SET balance_diff = 0 WHERE address = $1", ipA)
+	if err != nil {
+		t.Fatalf("Failed to corrupt balance checkpoints.\n  Error: %s", err)
+	}
+	drifts, err := VerifyBalanceCheckpoints(ctx, tx)
+	if err != nil {
+		t.Fatalf("Failed to verify balance checkpoints.\n  Error: %s", err)
+	}
+	drifted := slices.DeleteFunc(drifts, func(d BalanceCheckpointDrift) bool {
+		return d.Address != ipA
+	})
+	if len(drifted) != 3 {
+		t.Fatalf("Drift should be reported for every checkpoint of %s.\n  Drift: %+v", ipA, drifted)
+	}
+	if drifted[0].Expected != -5 || drifted[1].Expected != -3 || drifted[2].Expected != -8 || drifted[2].Actual != 0 {
+		t.Fatalf("Drift incorrect.\n  Drift: %+v", drifted)
+	}
+
+	_, err = RepairBalanceCheckpoints(ctx, tx)
+	if err != nil {
+		t.Fatalf("Failed to repair balance checkpoints.\n  Error: %s", err)
+	}
+	verify([]int64{0, -9, -7, -8})
+}
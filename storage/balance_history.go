@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const maxBalanceHistoryPoints = 1_000
+
+type GetBalanceHistoryRequest struct {
+	Address  netip.Addr
+	Start    time.Time
+	End      time.Time
+	Interval time.Duration
+}
+
+type BalancePoint struct {
+	Timestamp time.Time
+	Available int64
+}
+
+// GetBalanceHistory returns the balance of an address at Start and then every Interval until End, inclusive. Each
+// balance only counts the transfers created at or before its timestamp.
+func GetBalanceHistory(ctx context.Context, db dbConn, request GetBalanceHistoryRequest) ([]BalancePoint, error) {
+	if request.Start.Before(projectStarted) || request.End.Before(request.Start) || request.Interval <= 0 {
+		return nil, ErrBalanceHistoryRange
+	}
+	if request.End.Sub(request.Start)/request.Interval >= maxBalanceHistoryPoints {
+		return nil, fmt.Errorf("more than %d points requested: %w", maxBalanceHistoryPoints, ErrBalanceHistoryRange)
+	}
+
+	batch := &pgx.Batch{}
+
+	var startDiff int64
+	queueBalanceDiffAsOf(batch, request.Address, request.Start, &startDiff)
+
+	//language=sql
+	query := `
+SELECT created,
+       (CASE WHEN recipient = $1 THEN amount ELSE 0 END) - (CASE WHEN sender = $1 THEN amount ELSE 0 END)
+FROM transfer
+WHERE (sender = $1 OR recipient = $1)
+  AND created > $2
+  AND created <= $3
+ORDER BY created
+`
+	type change struct {
+		created time.Time
+		diff    int64
+	}
+	changes := make([]change, 0)
+	batch.Queue(query, request.Address, request.Start, request.End).Query(func(rows pgx.Rows) error {
+		for rows.Next() {
+			var c change
+			err := rows.Scan(&c.created, &c.diff)
+			if err != nil {
+				return fmt.Errorf("failed to scan balance history transfer: %w", err)
+			}
+			changes = append(changes, c)
+		}
+		return rows.Err()
+	})
+
+	err := db.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read balance history: %w", err)
+	}
+
+	points := make([]BalancePoint, 0)
+	diff := startDiff
+	for t := request.Start; !t.After(request.End); t = t.Add(request.Interval) {
+		for len(changes) > 0 && !changes[0].created.After(t) {
+			diff += changes[0].diff
+			changes = changes[1:]
+		}
+		points = append(points, BalancePoint{
+			Timestamp: t,
+			Available: BalanceUntouched(t) + diff,
+		})
+	}
+	return points, nil
+}
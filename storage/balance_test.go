@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"net/netip"
 	"testing"
 	"time"
@@ -31,3 +32,75 @@ func TestGetBalanceUntouched(t *testing.T) {
 		t.Errorf("GetBalance returned unexpected balance.\n  Expected: %v\n  Actual: %v", expectedBalance, balance)
 	}
 }
+
+func TestGetBalanceHistory(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ipA := netip.MustParseAddr("192.168.0.1")
+	ipB := netip.MustParseAddr("192.168.0.2")
+	start := now.Add(-3 * time.Hour)
+
+	tRequest := CreateTransferRequest{
+		Amount:    5,
+		Sender:    ipA,
+		Now:       start.Add(30 * time.Minute),
+		Recipient: ipB,
+	}
+	_, err = CreateTransfer(ctx, tx, tRequest)
+	if err != nil {
+		t.Fatalf("Failed to transfer.\n  Error: %s", err)
+	}
+	tRequest = CreateTransferRequest{
+		Amount:    2,
+		Sender:    ipB,
+		Now:       start.Add(90 * time.Minute),
+		Recipient: ipA,
+	}
+	_, err = CreateTransfer(ctx, tx, tRequest)
+	if err != nil {
+		t.Fatalf("Failed to transfer.\n  Error: %s", err)
+	}
+
+	request := GetBalanceHistoryRequest{
+		Address:  ipA,
+		Start:    start,
+		End:      start.Add(3 * time.Hour),
+		Interval: time.Hour,
+	}
+	points, err := GetBalanceHistory(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read balance history.\n  Error: %s", err)
+	}
+	expectedDiffs := []int64{0, -5, -3, -3}
+	if len(points) != len(expectedDiffs) {
+		t.Fatalf("Balance history should have %d points but has %d.", len(expectedDiffs), len(points))
+	}
+	for i, p := range points {
+		expectedTimestamp := start.Add(time.Duration(i) * time.Hour)
+		if !p.Timestamp.Equal(expectedTimestamp) {
+			t.Fatalf("Balance history point %d has unexpected timestamp.\n  Expected: %s\n  Actual: %s", i, expectedTimestamp, p.Timestamp)
+		}
+		expectedBalance := BalanceUntouched(expectedTimestamp) + expectedDiffs[i]
+		if p.Available != expectedBalance {
+			t.Fatalf("Balance history point %d has unexpected balance.\n  Expected: %d\n  Actual: %d", i, expectedBalance, p.Available)
+		}
+	}
+
+	request = GetBalanceHistoryRequest{
+		Address:  ipA,
+		Start:    now,
+		End:      now.Add(-time.Hour),
+		Interval: time.Hour,
+	}
+	_, err = GetBalanceHistory(ctx, tx, request)
+	if !errors.Is(err, ErrBalanceHistoryRange) {
+		t.Fatalf("Should have failed with invalid balance history range.\n  Error: %s", err)
+	}
+}
@@ -2,11 +2,14 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/netip"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Comment struct {
@@ -14,35 +17,238 @@ type Comment struct {
 	ID      uuid.UUID  `db:"id"`
 	Address netip.Addr `db:"address"`
 	Message string     `db:"message"`
+
+	// A reply has at most one parent, either a comment or a transfer.
+	ParentCommentID  *uuid.UUID `db:"parent_comment_id"`
+	ParentTransferID *uuid.UUID `db:"parent_transfer_id"`
+
+	// Revision starts at 0 and increases with every edit. Edited and Deleted are nil until the author edits or deletes
+	// the comment. A deleted comment is a tombstone with an empty message.
+	Revision int        `db:"revision"`
+	Edited   *time.Time `db:"edited"`
+	Deleted  *time.Time `db:"deleted"`
+}
+
+// CommentRevision is the message of a comment before an edit or deletion.
+type CommentRevision struct {
+	Created  time.Time
+	Revision int
+	Message  string
+}
+
+type EditCommentRequest struct {
+	CommentID uuid.UUID
+	Address   netip.Addr
+	Message   string
+	Now       time.Time
+}
+
+type DeleteCommentRequest struct {
+	CommentID uuid.UUID
+	Address   netip.Addr
+	Now       time.Time
 }
 
 type CreateCommentRequest struct {
 	Addr    netip.Addr
 	Message string
 	Now     time.Time
+
+	ParentCommentID  *uuid.UUID
+	ParentTransferID *uuid.UUID
 }
 
 type CreateCommentResponse struct {
 	Comment Comment
 }
 
+// CreateComment returns ErrCommentParentNotFound if the parent comment or transfer does not exist and
+// ErrCommentParentAmbiguous if both are set.
 func CreateComment(ctx context.Context, db dbConn, request CreateCommentRequest) (CreateCommentResponse, error) {
+	if request.ParentCommentID != nil && request.ParentTransferID != nil {
+		return CreateCommentResponse{}, ErrCommentParentAmbiguous
+	}
 	query := `
-INSERT INTO comment (created, id, address, message)
-VALUES ($1, $2, $3, $4)
+INSERT INTO comment (created, id, address, message, parent_comment_id, parent_transfer_id)
+VALUES ($1, $2, $3, $4, $5, $6)
 `
 	id := uuid.New()
-	_, err := db.Exec(ctx, query, request.Now, id, request.Addr, request.Message)
+	_, err := db.Exec(ctx, query, request.Now, id, request.Addr, request.Message, request.ParentCommentID, request.ParentTransferID)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgCodeForeignKeyViolation {
+			return CreateCommentResponse{}, ErrCommentParentNotFound
+		}
 		return CreateCommentResponse{}, fmt.Errorf("failed to insert new comment: %w", err)
 	}
 	response := CreateCommentResponse{
 		Comment: Comment{
-			Created: request.Now,
-			ID:      id,
-			Address: request.Addr,
-			Message: request.Message,
+			Created:          request.Now,
+			ID:               id,
+			Address:          request.Addr,
+			Message:          request.Message,
+			ParentCommentID:  request.ParentCommentID,
+			ParentTransferID: request.ParentTransferID,
 		},
 	}
+	err = updateAddressStats(ctx, db, AddressStats{Address: request.Addr, CommentCount: 1})
+	if err != nil {
+		return CreateCommentResponse{}, err
+	}
+	err = notifyFeed(ctx, db, FeedEvent{Comment: &response.Comment})
+	if err != nil {
+		return CreateCommentResponse{}, err
+	}
 	return response, nil
 }
+
+// ReadComment returns ErrCommentNotFound if the comment does not exist.
+func ReadComment(ctx context.Context, db dbConn, commentID uuid.UUID) (Comment, error) {
+	//language=sql
+	query := `
+SELECT created, id, address, message, parent_comment_id, parent_transfer_id, revision, edited, deleted
+FROM comment
+WHERE id = $1
+`
+	return readComment(ctx, db, query, commentID)
+}
+
+// EditComment replaces the message of a comment written by the address. The previous message is kept in the revision
+// history and the new revision is moderated again. It returns ErrCommentNotFound, ErrCommentNotAuthor, or
+// ErrCommentDeleted.
+func EditComment(ctx context.Context, db dbConn, request EditCommentRequest) (Comment, error) {
+	err := lockCommentForAuthor(ctx, db, request.CommentID, request.Address)
+	if err != nil {
+		return Comment{}, err
+	}
+	//language=sql
+	query := `
+WITH revision AS (
+    INSERT INTO comment_revision (created, comment_id, revision, message)
+        SELECT $2, id, revision, message
+        FROM comment
+        WHERE id = $1)
+UPDATE comment
+SET message  = $3,
+    revision = revision + 1,
+    edited   = $2
+WHERE id = $1
+RETURNING created, id, address, message, parent_comment_id, parent_transfer_id, revision, edited, deleted
+`
+	c, err := readComment(ctx, db, query, request.CommentID, request.Now, request.Message)
+	if err != nil {
+		return Comment{}, fmt.Errorf("failed to edit comment: %w", err)
+	}
+	err = resetCommentModerationAttempt(ctx, db, request.CommentID)
+	if err != nil {
+		return Comment{}, err
+	}
+	return c, nil
+}
+
+// DeleteComment turns a comment written by the address into a tombstone. The message is moved to the revision history
+// so reviewers can still see it. It returns ErrCommentNotFound, ErrCommentNotAuthor, or ErrCommentDeleted.
+func DeleteComment(ctx context.Context, db dbConn, request DeleteCommentRequest) (Comment, error) {
+	err := lockCommentForAuthor(ctx, db, request.CommentID, request.Address)
+	if err != nil {
+		return Comment{}, err
+	}
+	//language=sql
+	query := `
+WITH revision AS (
+    INSERT INTO comment_revision (created, comment_id, revision, message)
+        SELECT $2, id, revision, message
+        FROM comment
+        WHERE id = $1)
+UPDATE comment
+SET message  = '',
+    revision = revision + 1,
+    deleted  = $2
+WHERE id = $1
+RETURNING created, id, address, message, parent_comment_id, parent_transfer_id, revision, edited, deleted
+`
+	c, err := readComment(ctx, db, query, request.CommentID, request.Now)
+	if err != nil {
+		return Comment{}, fmt.Errorf("failed to delete comment: %w", err)
+	}
+	err = resetCommentModerationAttempt(ctx, db, request.CommentID)
+	if err != nil {
+		return Comment{}, err
+	}
+	return c, nil
+}
+
+// ReadCommentRevisions returns the previous messages of a comment, oldest first.
+func ReadCommentRevisions(ctx context.Context, db dbConn, commentID uuid.UUID) ([]CommentRevision, error) {
+	//language=sql
+	query := `
+SELECT created, revision, message
+FROM comment_revision
+WHERE comment_id = $1
+ORDER BY revision
+`
+	rows, err := db.Query(ctx, query, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comment revisions: %w", err)
+	}
+	revisions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[CommentRevision])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect comment revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+func readComment(ctx context.Context, db dbConn, query string, args ...any) (Comment, error) {
+	var c Comment
+	err := db.QueryRow(ctx, query, args...).Scan(&c.Created, &c.ID, &c.Address, &c.Message, &c.ParentCommentID, &c.ParentTransferID, &c.Revision, &c.Edited, &c.Deleted)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Comment{}, ErrCommentNotFound
+		}
+		return Comment{}, fmt.Errorf("failed to read comment: %w", err)
+	}
+	return c, nil
+}
+
+// lockCommentForAuthor locks the comment until the end of the transaction so a concurrent edit, deletion, or moderation
+// sees the change.
+func lockCommentForAuthor(ctx context.Context, db dbConn, commentID uuid.UUID, address netip.Addr) error {
+	//language=sql
+	query := `
+SELECT address, deleted IS NOT NULL
+FROM comment
+WHERE id = $1
+FOR UPDATE
+`
+	var author netip.Addr
+	var deleted bool
+	err := db.QueryRow(ctx, query, commentID).Scan(&author, &deleted)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		return fmt.Errorf("failed to lock comment: %w", err)
+	}
+	if author != address {
+		return ErrCommentNotAuthor
+	}
+	if deleted {
+		return ErrCommentDeleted
+	}
+	return nil
+}
+
+// resetCommentModerationAttempt clears the retry backoff so a new revision is moderated on the next pass.
+func resetCommentModerationAttempt(ctx context.Context, db dbConn, commentID uuid.UUID) error {
+	//language=sql
+	query := `
+DELETE
+FROM comment_moderation_attempt
+WHERE comment_id = $1
+`
+	_, err := db.Exec(ctx, query, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to reset comment moderation attempt: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,19 +16,60 @@ type CommentModeration struct {
 	CommentID uuid.UUID
 	Censored  bool
 	Note      string
+	// Reviewer is empty for automated verdicts.
+	Reviewer string
+	// Categories is empty unless the moderator reports per-category results.
+	Categories []ModerationCategory
+	// Revision is the comment revision the verdict was made for.
+	Revision int
+}
+
+// ModerationCategory is a moderator's result for one category, such as "harassment" or "violence/graphic". Score is
+// between 0 and 1.
+type ModerationCategory struct {
+	Name    string  `json:"name"`
+	Flagged bool    `json:"flagged"`
+	Score   float64 `json:"score"`
+}
+
+type CreateCommentVerdictRequest struct {
+	CommentID uuid.UUID
+	Censored  bool
+	Note      string
+	Reviewer  string
+	Now       time.Time
+}
+
+// FlaggedComment is a comment whose latest verdict censored it.
+type FlaggedComment struct {
+	Comment    Comment
+	Moderation CommentModeration
+}
+
+type ReadCommentFlaggedRequest struct {
+	After *FeedCursor
+}
+
+type ReadCommentFlaggedResponse struct {
+	Flagged []FlaggedComment
+	// Next is nil when there are no older flagged comments.
+	Next *FeedCursor
 }
 
 func CreateCommentModeration(ctx context.Context, db dbConn, moderations []CommentModeration, now time.Time) error {
 	batch := &pgx.Batch{}
 	//language=sql
 	query := `
-INSERT INTO comment_moderation (created, id, comment_id, censored, note)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING created, id
+INSERT INTO comment_moderation (created, id, comment_id, censored, note, reviewer, categories, revision)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 `
 	for _, m := range moderations {
 		u := uuid.New()
-		batch.Queue(query, now, u, m.CommentID, m.Censored, m.Note)
+		categories := m.Categories
+		if categories == nil {
+			categories = make([]ModerationCategory, 0)
+		}
+		batch.Queue(query, now, u, m.CommentID, m.Censored, m.Note, m.Reviewer, categories, m.Revision)
 	}
 	err := db.SendBatch(ctx, batch).Close()
 	if err != nil {
@@ -36,23 +78,35 @@ RETURNING created, id
 	return nil
 }
 
-func ReadCommentUnmoderated(ctx context.Context, db dbConn) ([]Comment, error) {
+// ClaimCommentUnmoderated claims up to limit comments whose current revision is unmoderated and whose next attempt is
+// due. Deleted comments are skipped. Claiming leases each comment by moving its next attempt to now plus lease, so
+// other workers skip it while it is moderated outside of a transaction. Rows are only locked while the claim runs and
+// the lock does not block foreign key checks from reactions, replies, or edits.
+func ClaimCommentUnmoderated(ctx context.Context, db dbConn, now time.Time, lease time.Duration, limit int) ([]Comment, error) {
 	//language=sql
 	query := `
-SELECT DISTINCT ON (c.id) c.created, c.id, c.address, c.message
-FROM comment c
-         LEFT JOIN comment_moderation m ON c.id = m.comment_id
-WHERE m.id IS NULL
-` // TODO Add limit?
-	rows, err := db.Query(ctx, query)
+WITH claimed AS (SELECT c.created, c.id, c.address, c.message, c.revision
+                 FROM comment c
+                          LEFT JOIN comment_moderation_attempt a ON c.id = a.comment_id
+                 WHERE NOT EXISTS (SELECT 1 FROM comment_moderation m WHERE m.comment_id = c.id AND m.revision = c.revision)
+                   AND c.deleted IS NULL
+                   AND (a.comment_id IS NULL OR a.next_attempt <= $1)
+                 ORDER BY c.created
+                 LIMIT $2 FOR NO KEY UPDATE OF c SKIP LOCKED),
+     leased AS (` + leaseModerationAttempt("comment_moderation_attempt", "comment_id") + `)
+SELECT created, id, address, message, revision
+FROM claimed
+ORDER BY created
+`
+	rows, err := db.Query(ctx, query, now, limit, lease.Seconds())
 	if err != nil {
-		return nil, fmt.Errorf("failed to read unmoderated comments: %w", err)
+		return nil, fmt.Errorf("failed to claim unmoderated comments: %w", err)
 	}
 	defer rows.Close()
 	unmoderated := make([]Comment, 0)
 	for rows.Next() {
 		var c Comment
-		err = rows.Scan(&c.Created, &c.ID, &c.Address, &c.Message)
+		err = rows.Scan(&c.Created, &c.ID, &c.Address, &c.Message, &c.Revision)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan unmoderated comments: %w", err)
 		}
@@ -65,13 +119,25 @@ WHERE m.id IS NULL
 	return unmoderated, nil
 }
 
+func CreateCommentModerationAttempt(ctx context.Context, db dbConn, commentIDs []uuid.UUID, attempt ModerationAttempt) error {
+	err := createModerationAttempt(ctx, db, "comment_moderation_attempt", "comment_id", commentIDs, attempt)
+	if err != nil {
+		return fmt.Errorf("failed to create comment moderation attempt: %w", err)
+	}
+	return nil
+}
+
+// ReadCommentCensored returns the IDs of comments whose latest verdict censored them, so a manual verdict overrides
+// earlier automated ones.
 func ReadCommentCensored(ctx context.Context, db dbConn, commentIDs []uuid.UUID) ([]uuid.UUID, error) {
 	//language=sql
 	query := `
-SELECT DISTINCT comment_id
-FROM comment_moderation
-WHERE comment_id = ANY ($1)
-  AND censored IS TRUE
+SELECT comment_id
+FROM (SELECT DISTINCT ON (comment_id) comment_id, censored
+      FROM comment_moderation
+      WHERE comment_id = ANY ($1)
+      ORDER BY comment_id, seq DESC) latest
+WHERE censored IS TRUE
 `
 	rows, err := db.Query(ctx, query, commentIDs)
 	if err != nil {
@@ -95,3 +161,127 @@ WHERE comment_id = ANY ($1)
 
 	return censored, nil
 }
+
+// ReadCommentModerationLatest returns the latest verdict for each moderated comment. Unmoderated comments are missing
+// from the map.
+func ReadCommentModerationLatest(ctx context.Context, db dbConn, commentIDs []uuid.UUID) (map[uuid.UUID]CommentModeration, error) {
+	//language=sql
+	query := `
+SELECT DISTINCT ON (comment_id) created, id, comment_id, censored, note, reviewer, categories, revision
+FROM comment_moderation
+WHERE comment_id = ANY ($1)
+ORDER BY comment_id, seq DESC
+`
+	rows, err := db.Query(ctx, query, commentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read latest comment moderation: %w", err)
+	}
+	moderations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[CommentModeration])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect latest comment moderation: %w", err)
+	}
+	latest := make(map[uuid.UUID]CommentModeration, len(moderations))
+	for _, m := range moderations {
+		latest[m.CommentID] = m
+	}
+	return latest, nil
+}
+
+// CreateCommentVerdict records a manual verdict from a reviewer for the current revision of the comment. It returns
+// ErrCommentNotFound if the comment does not exist.
+func CreateCommentVerdict(ctx context.Context, db dbConn, request CreateCommentVerdictRequest) (CommentModeration, error) {
+	//language=sql
+	query := `
+INSERT INTO comment_moderation (created, id, comment_id, censored, note, reviewer, categories, revision)
+SELECT $1, $2, id, $4, $5, $6, $7, revision
+FROM comment
+WHERE id = $3
+RETURNING revision
+`
+	m := CommentModeration{
+		Created:    request.Now,
+		ID:         uuid.New(),
+		CommentID:  request.CommentID,
+		Censored:   request.Censored,
+		Note:       request.Note,
+		Reviewer:   request.Reviewer,
+		Categories: make([]ModerationCategory, 0),
+	}
+	err := db.QueryRow(ctx, query, m.Created, m.ID, m.CommentID, m.Censored, m.Note, m.Reviewer, m.Categories).Scan(&m.Revision)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return CommentModeration{}, ErrCommentNotFound
+		}
+		return CommentModeration{}, fmt.Errorf("failed to create comment verdict: %w", err)
+	}
+	return m, nil
+}
+
+// ReadCommentModerationHistory returns every verdict for a comment, oldest first.
+func ReadCommentModerationHistory(ctx context.Context, db dbConn, commentID uuid.UUID) ([]CommentModeration, error) {
+	//language=sql
+	query := `
+SELECT created, id, comment_id, censored, note, reviewer, categories, revision
+FROM comment_moderation
+WHERE comment_id = $1
+ORDER BY seq
+`
+	rows, err := db.Query(ctx, query, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comment moderation history: %w", err)
+	}
+	history, err := pgx.CollectRows(rows, pgx.RowToStructByPos[CommentModeration])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect comment moderation history: %w", err)
+	}
+	return history, nil
+}
+
+// ReadCommentFlagged pages through censored comments that are not deleted, newest first.
+func ReadCommentFlagged(ctx context.Context, db dbConn, request ReadCommentFlaggedRequest) (response ReadCommentFlaggedResponse, err error) {
+	q := psql.Select("c.created, c.id, c.address, c.message, m.created, m.id, m.censored, m.note, m.reviewer, m.categories, m.revision").
+		From("comment c").
+		JoinClause(`JOIN LATERAL (SELECT created, id, censored, note, reviewer, categories, revision
+                      FROM comment_moderation
+                      WHERE comment_id = c.id
+                      ORDER BY seq DESC
+                      LIMIT 1) m ON TRUE`).
+		Where("m.censored IS TRUE").
+		Where("c.deleted IS NULL")
+	if request.After != nil {
+		q = q.Where("(c.created, c.id) < (?, ?)", request.After.Created, request.After.ID)
+	}
+	query, args, err := q.OrderBy("c.created DESC", "c.id DESC").Limit(feedLimit + 1).ToSql()
+	if err != nil {
+		return response, fmt.Errorf("failed to build flagged comment SQL query: %w", err)
+	}
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return response, fmt.Errorf("failed to read flagged comments: %w", err)
+	}
+	defer rows.Close()
+	response.Flagged = make([]FlaggedComment, 0)
+	for rows.Next() {
+		var f FlaggedComment
+		err = rows.Scan(&f.Comment.Created, &f.Comment.ID, &f.Comment.Address, &f.Comment.Message,
+			&f.Moderation.Created, &f.Moderation.ID, &f.Moderation.Censored, &f.Moderation.Note, &f.Moderation.Reviewer, &f.Moderation.Categories, &f.Moderation.Revision)
+		if err != nil {
+			return response, fmt.Errorf("failed to scan flagged comments: %w", err)
+		}
+		f.Moderation.CommentID = f.Comment.ID
+		response.Flagged = append(response.Flagged, f)
+	}
+	err = rows.Err()
+	if err != nil {
+		return response, fmt.Errorf("failed to iterate over flagged comment rows: %w", err)
+	}
+	if len(response.Flagged) > feedLimit {
+		response.Flagged = response.Flagged[:feedLimit]
+		last := response.Flagged[feedLimit-1].Comment
+		response.Next = &FeedCursor{
+			Created: last.Created,
+			ID:      last.ID,
+		}
+	}
+	return response, nil
+}
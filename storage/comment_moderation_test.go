@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCommentModerationAttempt(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	request := CreateCommentRequest{
+		Addr:    netip.MustParseAddr("192.168.9.1"),
+		Message: "test",
+		Now:     now,
+	}
+	response, err := CreateComment(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to write comment.\n  Error: %s", err)
+	}
+	commentID := response.Comment.ID
+	claimed := func(at time.Time) bool {
+		unmoderated, err := ClaimCommentUnmoderated(ctx, tx, at, time.Second, 1000)
+		if err != nil {
+			t.Fatalf("Failed to claim unmoderated comments.\n  Error: %s", err)
+		}
+		return slices.ContainsFunc(unmoderated, func(c Comment) bool { return c.ID == commentID })
+	}
+	if !claimed(now) {
+		t.Fatalf("New comment should be claimed.")
+	}
+	if claimed(now) {
+		t.Fatalf("Leased comment should not be claimed again before the lease ends.")
+	}
+
+	attempt := ModerationAttempt{
+		Now:        now,
+		Error:      "moderator unavailable",
+		Backoff:    time.Minute,
+		MaxBackoff: 3 * time.Minute,
+	}
+	for range 3 {
+		err = CreateCommentModerationAttempt(ctx, tx, []uuid.UUID{commentID}, attempt)
+		if err != nil {
+			t.Fatalf("Failed to create comment moderation attempt.\n  Error: %s", err)
+		}
+	}
+	status, err := ReadCommentModerationAttempt(ctx, tx, commentID)
+	if err != nil {
+		t.Fatalf("Failed to read comment moderation attempt.\n  Error: %s", err)
+	}
+	if status.Attempts != 3 {
+		t.Fatalf("Unexpected attempt count.\n  Expected: 3\n  Actual: %d", status.Attempts)
+	}
+	if status.LastError != attempt.Error {
+		t.Fatalf("Unexpected last error.\n  Expected: %q\n  Actual: %q", attempt.Error, status.LastError)
+	}
+	// Backoff doubles from one minute and is capped at three.
+	if status.NextAttempt.Sub(now.Add(3*time.Minute)).Abs() > time.Millisecond {
+		t.Fatalf("Unexpected next attempt.\n  Expected: %s\n  Actual: %s", now.Add(3*time.Minute), status.NextAttempt)
+	}
+	if claimed(now.Add(2 * time.Minute)) {
+		t.Fatalf("Comment should not be claimed before its next attempt.")
+	}
+	if !claimed(now.Add(4 * time.Minute)) {
+		t.Fatalf("Comment should be claimed after its next attempt.")
+	}
+
+	err = CreateCommentModeration(ctx, tx, []CommentModeration{{CommentID: commentID, Note: "test"}}, now)
+	if err != nil {
+		t.Fatalf("Failed to create comment moderation.\n  Error: %s", err)
+	}
+	if claimed(now.Add(4 * time.Minute)) {
+		t.Fatalf("Moderated comment should not be claimed.")
+	}
+}
+
+func TestReadCommentModerationLatest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	request := CreateCommentRequest{
+		Addr:    netip.MustParseAddr("192.168.9.2"),
+		Message: "test",
+		Now:     now,
+	}
+	response, err := CreateComment(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to write comment.\n  Error: %s", err)
+	}
+	commentID := response.Comment.ID
+
+	categories := []ModerationCategory{{Name: "violence", Flagged: true, Score: 0.9}}
+	moderations := []CommentModeration{
+		{CommentID: commentID, Censored: true, Note: "automated", Categories: categories},
+		{CommentID: commentID, Censored: false, Note: "manual", Reviewer: "test"},
+	}
+	for _, m := range moderations {
+		err = CreateCommentModeration(ctx, tx, []CommentModeration{m}, now)
+		if err != nil {
+			t.Fatalf("Failed to create comment moderation.\n  Error: %s", err)
+		}
+	}
+
+	latest, err := ReadCommentModerationLatest(ctx, tx, []uuid.UUID{commentID})
+	if err != nil {
+		t.Fatalf("Failed to read latest comment moderation.\n  Error: %s", err)
+	}
+	m, ok := latest[commentID]
+	if !ok || m.Censored || m.Reviewer != "test" {
+		t.Fatalf("Latest verdict should be the manual verdict.\n  Actual: %+v", m)
+	}
+
+	history, err := ReadCommentModerationHistory(ctx, tx, commentID)
+	if err != nil {
+		t.Fatalf("Failed to read comment moderation history.\n  Error: %s", err)
+	}
+	if len(history) != 2 || !slices.Equal(history[0].Categories, categories) {
+		t.Fatalf("Categories should be stored with the verdict.\n  Actual: %+v", history)
+	}
+}
@@ -2,6 +2,31 @@ package storage
 
 import "errors"
 
+// PostgreSQL error codes from https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	pgCodeForeignKeyViolation = "23503"
+	pgCodeUniqueViolation     = "23505"
+)
+
 var (
-	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrBalanceHistoryRange    = errors.New("invalid balance history range")
+	ErrCommentDeleted         = errors.New("comment is deleted")
+	ErrCommentNotAuthor       = errors.New("comment was written by a different address")
+	ErrCommentNotFound        = errors.New("comment not found")
+	ErrCommentParentAmbiguous = errors.New("comment must not reply to both a comment and a transfer")
+	ErrCommentParentNotFound  = errors.New("comment parent not found")
+	ErrFeedFilter             = errors.New("invalid feed filter")
+	ErrIdempotencyKeyInUse    = errors.New("idempotency key is being used by a concurrent transfer")
+	ErrIdempotencyKeyReuse    = errors.New("idempotency key was used for a different transfer")
+	ErrInsufficientBalance    = errors.New("insufficient balance")
+	ErrLeaderboardPage        = errors.New("leaderboard limit or offset is out of range")
+	ErrPrefixInvalid          = errors.New("invalid address prefix")
+	ErrRateLimitWait          = errors.New("rate limit token would not be available in time")
+	ErrReactionKind           = errors.New("reaction kind is not allowed")
+	ErrReactionTarget         = errors.New("reaction must target exactly one comment or transfer")
+	ErrReactionTargetNotFound = errors.New("reaction target not found")
+	ErrSearchOffset           = errors.New("search offset is out of range")
+	ErrSchemaOutdated         = errors.New("database schema is older than expected, run migrations")
+	ErrSchemaUnknown          = errors.New("database schema has a migration unknown to this build")
+	ErrTransferNotFound       = errors.New("transfer not found")
 )
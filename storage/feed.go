@@ -7,18 +7,59 @@ import (
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
 const feedLimit = 100
 
+// FeedCursor is the position of the last row read from a feed. Rows are ordered by (created, id) descending, so
+// ties on created break deterministically.
+type FeedCursor struct {
+	Created time.Time `json:"created"`
+	ID      uuid.UUID `json:"id"`
+}
+
+// FeedKind selects a single feed. The empty FeedKind reads both.
+type FeedKind string
+
+const (
+	FeedKindComment  FeedKind = "comment"
+	FeedKindTransfer FeedKind = "transfer"
+)
+
 type GetFeedRequest struct {
 	Now     time.Time
 	Address *netip.Addr
+	// Prefix filters like Address but matches every address in the prefix. Only one of Address and Prefix may be set.
+	Prefix *netip.Prefix
+
+	// CreatedAfter and CreatedBefore are exclusive bounds on when a row was created.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// MinAmount and MaxAmount are inclusive bounds on the transfer amount. They do not filter comments.
+	MinAmount *int64
+	MaxAmount *int64
+	// Direction only returns transfers sent or received by Address or Prefix. It requires one of them and does not
+	// filter comments.
+	// The empty StatementDirection returns both.
+	Direction StatementDirection
+	Kind      FeedKind
+
+	// CommentAfter and TransferAfter resume their feeds after the given cursor. SkipComment and SkipTransfer omit a
+	// feed entirely, such as when it has already been exhausted.
+	CommentAfter  *FeedCursor
+	TransferAfter *FeedCursor
+	SkipComment   bool
+	SkipTransfer  bool
 }
 
 type GetFeedResponse struct {
 	Feed Feed
+
+	// CommentNext and TransferNext are nil when there are no older rows.
+	CommentNext  *FeedCursor
+	TransferNext *FeedCursor
 }
 
 type Feed struct {
@@ -28,37 +69,98 @@ type Feed struct {
 }
 
 func GetFeed(ctx context.Context, db dbConn, request GetFeedRequest) (response GetFeedResponse, err error) {
-	batch := &pgx.Batch{}
-
-	q := psql.Select("created, id, address, message").From("comment")
-	if request.Address != nil {
-		q = q.Where(sq.Eq{"address": request.Address})
-	}
-	query, args, err := q.OrderBy("created DESC").Limit(feedLimit).ToSql()
+	err = request.validate()
 	if err != nil {
-		return response, fmt.Errorf("failed to build GetFeed comment SQL query: %w", err)
+		return response, err
 	}
-	batch.Queue(query, args...).Query(func(rows pgx.Rows) error {
-		response.Feed.Comment, err = pgx.CollectRows(rows, pgx.RowToStructByName[Comment])
+	batch := &pgx.Batch{}
+
+	response.Feed.Comment = make([]Comment, 0)
+	if !request.SkipComment && request.Kind != FeedKindTransfer {
+		q := psql.Select("created, id, address, message, parent_comment_id, parent_transfer_id, revision, edited, deleted").From("comment")
+		if request.Address != nil {
+			q = q.Where(sq.Eq{"address": request.Address})
+		}
+		if request.Prefix != nil {
+			q = q.Where("address <<= ?", request.Prefix.Masked())
+		}
+		q = request.whereCreated(q)
+		if request.CommentAfter != nil {
+			q = q.Where("(created, id) < (?, ?)", request.CommentAfter.Created, request.CommentAfter.ID)
+		}
+		query, args, err := q.OrderBy("created DESC", "id DESC").Limit(feedLimit + 1).ToSql()
 		if err != nil {
-			return fmt.Errorf("failed to collect feed comments: %w", err)
+			return response, fmt.Errorf("failed to build GetFeed comment SQL query: %w", err)
 		}
-		return nil
-	})
-
-	//language=sql
-	q = psql.Select("created, id, sender, recipient, amount").From("transfer")
-	if request.Address != nil {
-		q = q.Where("sender = $1 OR recipient = $1", request.Address)
+		batch.Queue(query, args...).Query(func(rows pgx.Rows) error {
+			response.Feed.Comment, err = pgx.CollectRows(rows, pgx.RowToStructByName[Comment])
+			if err != nil {
+				return fmt.Errorf("failed to collect feed comments: %w", err)
+			}
+			if len(response.Feed.Comment) > feedLimit {
+				response.Feed.Comment = response.Feed.Comment[:feedLimit]
+				last := response.Feed.Comment[feedLimit-1]
+				response.CommentNext = &FeedCursor{
+					Created: last.Created,
+					ID:      last.ID,
+				}
+			}
+			return nil
+		})
 	}
-	query, args, err = q.OrderBy("created DESC").Limit(feedLimit).ToSql()
-	batch.Queue(query, args...).Query(func(rows pgx.Rows) error {
-		response.Feed.Transfer, err = pgx.CollectRows(rows, pgx.RowToStructByName[Transfer])
+
+	response.Feed.Transfer = make([]Transfer, 0)
+	if !request.SkipTransfer && request.Kind != FeedKindComment {
+		q := psql.Select("created, id, sender, recipient, amount, memo").From("transfer")
+		var sender, recipient sq.Sqlizer
+		switch {
+		case request.Address != nil:
+			sender = sq.Eq{"sender": request.Address}
+			recipient = sq.Eq{"recipient": request.Address}
+		case request.Prefix != nil:
+			sender = sq.Expr("sender <<= ?", request.Prefix.Masked())
+			recipient = sq.Expr("recipient <<= ?", request.Prefix.Masked())
+		}
+		if sender != nil {
+			switch request.Direction {
+			case StatementDirectionSent:
+				q = q.Where(sender)
+			case StatementDirectionReceived:
+				q = q.Where(recipient)
+			default:
+				q = q.Where(sq.Or{sender, recipient})
+			}
+		}
+		q = request.whereCreated(q)
+		if request.MinAmount != nil {
+			q = q.Where(sq.GtOrEq{"amount": *request.MinAmount})
+		}
+		if request.MaxAmount != nil {
+			q = q.Where(sq.LtOrEq{"amount": *request.MaxAmount})
+		}
+		if request.TransferAfter != nil {
+			q = q.Where("(created, id) < (?, ?)", request.TransferAfter.Created, request.TransferAfter.ID)
+		}
+		query, args, err := q.OrderBy("created DESC", "id DESC").Limit(feedLimit + 1).ToSql()
 		if err != nil {
-			return fmt.Errorf("failed to collect feed transfers: %w", err)
+			return response, fmt.Errorf("failed to build GetFeed transfer SQL query: %w", err)
 		}
-		return nil
-	})
+		batch.Queue(query, args...).Query(func(rows pgx.Rows) error {
+			response.Feed.Transfer, err = pgx.CollectRows(rows, pgx.RowToStructByName[Transfer])
+			if err != nil {
+				return fmt.Errorf("failed to collect feed transfers: %w", err)
+			}
+			if len(response.Feed.Transfer) > feedLimit {
+				response.Feed.Transfer = response.Feed.Transfer[:feedLimit]
+				last := response.Feed.Transfer[feedLimit-1]
+				response.TransferNext = &FeedCursor{
+					Created: last.Created,
+					ID:      last.ID,
+				}
+			}
+			return nil
+		})
+	}
 
 	err = db.SendBatch(ctx, batch).Close()
 	if err != nil {
@@ -67,3 +169,43 @@ func GetFeed(ctx context.Context, db dbConn, request GetFeedRequest) (response G
 	response.Feed.Timestamp = request.Now
 	return response, nil
 }
+
+func (request GetFeedRequest) validate() error {
+	switch request.Kind {
+	case "", FeedKindComment, FeedKindTransfer:
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrFeedFilter, request.Kind)
+	}
+	if request.Address != nil && request.Prefix != nil {
+		return fmt.Errorf("%w: only one of address and prefix may be set", ErrFeedFilter)
+	}
+	if request.Prefix != nil && !request.Prefix.IsValid() {
+		return fmt.Errorf("%w: %w", ErrFeedFilter, ErrPrefixInvalid)
+	}
+	switch request.Direction {
+	case "":
+	case StatementDirectionSent, StatementDirectionReceived:
+		if request.Address == nil && request.Prefix == nil {
+			return fmt.Errorf("%w: direction requires an address or prefix", ErrFeedFilter)
+		}
+	default:
+		return fmt.Errorf("%w: unknown direction %q", ErrFeedFilter, request.Direction)
+	}
+	if request.MinAmount != nil && request.MaxAmount != nil && *request.MinAmount > *request.MaxAmount {
+		return fmt.Errorf("%w: minimum amount is greater than maximum amount", ErrFeedFilter)
+	}
+	if request.CreatedAfter != nil && request.CreatedBefore != nil && !request.CreatedAfter.Before(*request.CreatedBefore) {
+		return fmt.Errorf("%w: created after must be before created before", ErrFeedFilter)
+	}
+	return nil
+}
+
+func (request GetFeedRequest) whereCreated(q sq.SelectBuilder) sq.SelectBuilder {
+	if request.CreatedAfter != nil {
+		q = q.Where(sq.Gt{"created": *request.CreatedAfter})
+	}
+	if request.CreatedBefore != nil {
+		q = q.Where(sq.Lt{"created": *request.CreatedBefore})
+	}
+	return q
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"net/netip"
 	"testing"
 	"time"
@@ -139,6 +140,188 @@ func TestGetFeed_Empty(t *testing.T) {
 	}
 }
 
+func TestGetFeed_Pagination(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ipA := netip.MustParseAddr("192.168.0.1")
+	ipB := netip.MustParseAddr("192.168.0.2")
+
+	const extra = 5
+	for range feedLimit + extra {
+		cRequest := CreateCommentRequest{
+			Addr:    ipA,
+			Message: "test",
+			Now:     now, // Identical timestamps must still paginate deterministically.
+		}
+		_, err = CreateComment(ctx, tx, cRequest)
+		if err != nil {
+			t.Fatalf("Failed to write comment.\n  Error: %s", err)
+		}
+	}
+	tRequest := CreateTransferRequest{
+		Amount:    1,
+		Sender:    ipA,
+		Now:       now,
+		Recipient: ipB,
+	}
+	_, err = CreateTransfer(ctx, tx, tRequest)
+	if err != nil {
+		t.Fatalf("Failed to transfer.\n  Error: %s", err)
+	}
+
+	request := GetFeedRequest{
+		Now:     now,
+		Address: &ipA,
+	}
+	response, err := GetFeed(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read feed.\n  Error: %s", err)
+	}
+	if len(response.Feed.Comment) != feedLimit {
+		t.Fatalf("First comment page should have %d entries but has %d.", feedLimit, len(response.Feed.Comment))
+	}
+	if len(response.Feed.Transfer) != 1 {
+		t.Fatalf("First transfer page should have 1 entry but has %d.", len(response.Feed.Transfer))
+	}
+	if response.CommentNext == nil {
+		t.Fatalf("Comment cursor should be set when there are older comments.")
+	}
+	if response.TransferNext != nil {
+		t.Fatalf("Transfer cursor should be nil when there are no older transfers.")
+	}
+	seen := make(map[uuid.UUID]bool, feedLimit+extra)
+	for _, c := range response.Feed.Comment {
+		seen[c.ID] = true
+	}
+
+	request = GetFeedRequest{
+		Now:          now,
+		Address:      &ipA,
+		CommentAfter: response.CommentNext,
+		SkipTransfer: true,
+	}
+	response, err = GetFeed(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read feed.\n  Error: %s", err)
+	}
+	if len(response.Feed.Comment) != extra {
+		t.Fatalf("Second comment page should have %d entries but has %d.", extra, len(response.Feed.Comment))
+	}
+	if len(response.Feed.Transfer) != 0 {
+		t.Fatalf("Skipped transfer feed should be empty.")
+	}
+	if response.CommentNext != nil || response.TransferNext != nil {
+		t.Fatalf("Cursors should be nil on the last page.")
+	}
+	for _, c := range response.Feed.Comment {
+		if seen[c.ID] {
+			t.Fatalf("Comment %s appeared on more than one page.", c.ID)
+		}
+		seen[c.ID] = true
+	}
+	if len(seen) != feedLimit+extra {
+		t.Fatalf("Pages should cover %d comments but cover %d.", feedLimit+extra, len(seen))
+	}
+}
+
+func TestGetFeed_Filter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ipA := netip.MustParseAddr("192.168.13.1")
+	ipB := netip.MustParseAddr("192.168.13.2")
+
+	for i, amount := range []int64{5, 50, 500} {
+		tRequest := CreateTransferRequest{
+			Amount:    amount,
+			Sender:    ipA,
+			Now:       now.Add(time.Duration(i) * time.Hour),
+			Recipient: ipB,
+		}
+		_, err = CreateTransfer(ctx, tx, tRequest)
+		if err != nil {
+			t.Fatalf("Failed to transfer.\n  Error: %s", err)
+		}
+	}
+	cRequest := CreateCommentRequest{
+		Addr:    ipA,
+		Message: "test",
+		Now:     now,
+	}
+	_, err = CreateComment(ctx, tx, cRequest)
+	if err != nil {
+		t.Fatalf("Failed to write comment.\n  Error: %s", err)
+	}
+
+	minAmount := int64(10)
+	maxAmount := int64(100)
+	request := GetFeedRequest{
+		Now:       now,
+		Address:   &ipA,
+		MinAmount: &minAmount,
+		MaxAmount: &maxAmount,
+		Direction: StatementDirectionSent,
+		Kind:      FeedKindTransfer,
+	}
+	response, err := GetFeed(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read feed.\n  Error: %s", err)
+	}
+	if len(response.Feed.Comment) != 0 {
+		t.Fatalf("Comment feed should be empty when only transfers are requested.")
+	}
+	if len(response.Feed.Transfer) != 1 || response.Feed.Transfer[0].Amount != 50 {
+		t.Fatalf("Transfer feed should only have the transfer within the amount range.")
+	}
+
+	request.Direction = StatementDirectionReceived
+	response, err = GetFeed(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read feed.\n  Error: %s", err)
+	}
+	if len(response.Feed.Transfer) != 0 {
+		t.Fatalf("Transfer feed should be empty for received transfers.")
+	}
+
+	after := now.Add(30 * time.Minute)
+	before := now.Add(90 * time.Minute)
+	request = GetFeedRequest{
+		Now:           now,
+		Address:       &ipB,
+		CreatedAfter:  &after,
+		CreatedBefore: &before,
+	}
+	response, err = GetFeed(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read feed.\n  Error: %s", err)
+	}
+	if len(response.Feed.Transfer) != 1 || response.Feed.Transfer[0].Amount != 50 {
+		t.Fatalf("Transfer feed should only have the transfer within the time range.")
+	}
+
+	request = GetFeedRequest{
+		Now:       now,
+		Direction: StatementDirectionSent,
+	}
+	_, err = GetFeed(ctx, tx, request)
+	if !errors.Is(err, ErrFeedFilter) {
+		t.Fatalf("Direction without an address should fail.\n  Error: %s", err)
+	}
+}
+
 func feedCommentCheck(t *testing.T, prefix string, comment Comment, created time.Time, address netip.Addr, message string) {
 	if comment.ID == uuid.Nil {
 		t.Fatal(prefix + "Comment ID must be non-nil.")
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// feedChannel is the PostgreSQL LISTEN/NOTIFY channel for new comments and transfers. Notifications are only delivered
+// when the inserting transaction commits, so every server replica sees exactly the committed rows.
+const feedChannel = "ipcoin_feed"
+
+// FeedEvent holds exactly one of a new comment or transfer.
+type FeedEvent struct {
+	Comment  *Comment  `json:"comment,omitempty"`
+	Transfer *Transfer `json:"transfer,omitempty"`
+}
+
+func notifyFeed(ctx context.Context, db dbConn, event FeedEvent) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal feed event: %w", err)
+	}
+	_, err = db.Exec(ctx, "SELECT pg_notify($1, $2)", feedChannel, string(b))
+	if err != nil {
+		return fmt.Errorf("failed to notify feed: %w", err)
+	}
+	return nil
+}
+
+func queueNotifyFeed(batch *pgx.Batch, event FeedEvent) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal feed event: %w", err)
+	}
+	batch.Queue("SELECT pg_notify($1, $2)", feedChannel, string(b))
+	return nil
+}
+
+// ListenFeed calls handle for every committed comment and transfer until the context is canceled or the connection
+// fails. It takes a connection out of the pool for as long as it listens.
+func ListenFeed(ctx context.Context, pool *pgxpool.Pool, handle func(event FeedEvent)) error {
+	pooled, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection to listen for feed: %w", err)
+	}
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+feedChannel)
+	if err != nil {
+		return fmt.Errorf("failed to listen for feed: %w", err)
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for feed notification: %w", err)
+		}
+		var event FeedEvent
+		err = json.Unmarshal([]byte(notification.Payload), &event)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal feed notification: %w", err)
+		}
+		handle(event)
+	}
+}
@@ -3,8 +3,8 @@ package storage
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"net/netip"
-	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -23,52 +23,96 @@ type GetGlanceRequest struct {
 }
 
 func GetGlance(ctx context.Context, db dbConn, request GetGlanceRequest) (Glance, int64, error) {
-	batch := &pgx.Batch{}
+	stats, err := ReadAddressStats(ctx, db, request.Address)
+	if err != nil {
+		return Glance{}, 0, err
+	}
+	balanceUntouched := BalanceUntouched(request.Now)
+	glance := Glance{
+		Address:          request.Address,
+		BalanceAvailable: balanceUntouched + stats.BalanceDiff,
+		CommentCount:     stats.CommentCount,
+		TransferCount:    stats.TransferCount,
+	}
+	return glance, balanceUntouched, nil
+}
+
+// GlancePrefix aggregates every address in a prefix. Each address accrues the untouched balance, so the balance can be
+// far larger than an int64 for short IPv6 prefixes.
+type GlancePrefix struct {
+	Prefix           netip.Prefix
+	HostCount        *big.Int
+	BalanceAvailable *big.Int
+	CommentCount     int64
+	TransferCount    int64
+}
+
+type GetGlancePrefixRequest struct {
+	Prefix netip.Prefix
+	Now    time.Time
+}
 
-	balanceDiff := &atomic.Int64{}
-	addBalanceDiff(request.Address, batch, balanceDiff)
+// GetGlancePrefix is GetGlance for every address in a prefix. Transfers between two addresses in the prefix are counted
+// once and do not change the balance.
+func GetGlancePrefix(ctx context.Context, db dbConn, request GetGlancePrefixRequest) (GlancePrefix, int64, error) {
+	if !request.Prefix.IsValid() {
+		return GlancePrefix{}, 0, ErrPrefixInvalid
+	}
+	prefix := request.Prefix.Masked()
+	batch := &pgx.Batch{}
 
 	//language=sql
 	query := `
 SELECT COUNT(*)
 FROM comment
-WHERE address = $1
+WHERE address <<= $1
 `
 	var commentCount int64
-	batch.Queue(query, request.Address).QueryRow(func(row pgx.Row) error {
+	batch.Queue(query, prefix).QueryRow(func(row pgx.Row) error {
 		err := row.Scan(&commentCount)
 		if err != nil {
-			return fmt.Errorf("failed to count comments: %w", err)
+			return fmt.Errorf("failed to count comments in prefix: %w", err)
 		}
 		return nil
 	})
 
 	//language=sql
 	query = `
-SELECT COUNT(*)
+SELECT COALESCE(SUM(amount) FILTER (WHERE recipient <<= $1), 0) - COALESCE(SUM(amount) FILTER (WHERE sender <<= $1), 0),
+       COUNT(*)
 FROM transfer
-WHERE recipient = $1
-   OR sender = $1
+WHERE recipient <<= $1
+   OR sender <<= $1
 `
-	var transferCount int64
-	batch.Queue(query, request.Address).QueryRow(func(row pgx.Row) error {
-		err := row.Scan(&transferCount)
+	var balanceDiff, transferCount int64
+	batch.Queue(query, prefix).QueryRow(func(row pgx.Row) error {
+		err := row.Scan(&balanceDiff, &transferCount)
 		if err != nil {
-			return fmt.Errorf("failed to count transfers: %w", err)
+			return fmt.Errorf("failed to sum transfers in prefix: %w", err)
 		}
 		return nil
 	})
 
 	err := db.SendBatch(ctx, batch).Close()
 	if err != nil {
-		return Glance{}, 0, err
+		return GlancePrefix{}, 0, err
 	}
 	balanceUntouched := BalanceUntouched(request.Now)
-	glance := Glance{
-		Address:          request.Address,
-		BalanceAvailable: balanceUntouched + balanceDiff.Load(),
+	hostCount := PrefixHostCount(prefix)
+	balance := new(big.Int).Mul(hostCount, big.NewInt(balanceUntouched))
+	balance.Add(balance, big.NewInt(balanceDiff))
+	glance := GlancePrefix{
+		Prefix:           prefix,
+		HostCount:        hostCount,
+		BalanceAvailable: balance,
 		CommentCount:     commentCount,
 		TransferCount:    transferCount,
 	}
 	return glance, balanceUntouched, nil
 }
+
+// PrefixHostCount is the number of addresses in the prefix, including network and broadcast addresses because each of
+// them has a balance.
+func PrefixHostCount(prefix netip.Prefix) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(prefix.Addr().BitLen()-prefix.Bits()))
+}
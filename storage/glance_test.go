@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"math/big"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestGetGlancePrefix(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	inside := netip.MustParseAddr("192.168.14.1")
+	insideOther := netip.MustParseAddr("192.168.14.2")
+	outside := netip.MustParseAddr("192.168.15.1")
+	transfers := []CreateTransferRequest{
+		{Amount: 3, Sender: inside, Recipient: insideOther},
+		{Amount: 5, Sender: inside, Recipient: outside},
+		{Amount: 7, Sender: outside, Recipient: insideOther},
+	}
+	for _, tRequest := range transfers {
+		tRequest.Now = now
+		_, err = CreateTransfer(ctx, tx, tRequest)
+		if err != nil {
+			t.Fatalf("Failed to transfer.\n  Error: %s", err)
+		}
+	}
+	_, err = CreateComment(ctx, tx, CreateCommentRequest{
+		Addr:    inside,
+		Message: "test",
+		Now:     now,
+	})
+	if err != nil {
+		t.Fatalf("Failed to write comment.\n  Error: %s", err)
+	}
+
+	request := GetGlancePrefixRequest{
+		Prefix: netip.MustParsePrefix("192.168.14.77/24"),
+		Now:    now,
+	}
+	glance, balanceUntouched, err := GetGlancePrefix(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to get glance for prefix.\n  Error: %s", err)
+	}
+	if glance.Prefix != netip.MustParsePrefix("192.168.14.0/24") {
+		t.Fatalf("Prefix should be masked.\n  Actual: %s", glance.Prefix)
+	}
+	if glance.HostCount.Cmp(big.NewInt(256)) != 0 {
+		t.Fatalf("Host count should be 256 but is %s.", glance.HostCount)
+	}
+	expected := big.NewInt(256*balanceUntouched + 7 - 5)
+	if glance.BalanceAvailable.Cmp(expected) != 0 {
+		t.Fatalf("Balance incorrect.\n  Expected: %s\n  Actual: %s", expected, glance.BalanceAvailable)
+	}
+	if glance.CommentCount != 1 {
+		t.Fatalf("Comment count should be 1 but is %d.", glance.CommentCount)
+	}
+	if glance.TransferCount != 3 {
+		t.Fatalf("Transfer count should be 3 but is %d.", glance.TransferCount)
+	}
+
+	request.Prefix = netip.MustParsePrefix("::/0")
+	glance, _, err = GetGlancePrefix(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to get glance for prefix.\n  Error: %s", err)
+	}
+	if glance.BalanceAvailable.IsInt64() {
+		t.Fatalf("Balance of the whole IPv6 space should not fit in an int64.")
+	}
+}
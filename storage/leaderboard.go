@@ -3,95 +3,197 @@ package storage
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"net/netip"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
-const leaderboardLimit = 10
+const (
+	leaderboardLimit = 10
+	// LeaderboardMaxLimit and LeaderboardMaxOffset bound the pages clients can request. GetLeaderboard never reads past
+	// LeaderboardMaxOffset+LeaderboardMaxLimit entries, so one read with that limit holds every page.
+	LeaderboardMaxLimit  = 100
+	LeaderboardMaxOffset = 1_000
+)
+
+const (
+	// DefaultLeaderboardPrefixIPv4 groups IPv4 addresses into the prefix lengths usually assigned to one organization.
+	DefaultLeaderboardPrefixIPv4 = 24
+	DefaultLeaderboardPrefixIPv6 = 48
+)
 
 type GetLeaderboardRequest struct {
 	Now time.Time
+
+	// PrefixIPv4 and PrefixIPv6 are the prefix lengths the prefix leaderboards group by. Zero uses the default.
+	PrefixIPv4 int
+	PrefixIPv6 int
+
+	// Limit is the number of entries in each section. Zero uses the default of 10. Offset skips that many entries of
+	// every section.
+	Limit  int
+	Offset int
 }
 
 type GetLeaderboardResponse struct {
 	LeaderboardBalance  []Glance
 	LeaderboardTransfer []Glance
-}
+	// LeaderboardComment only includes addresses that have commented.
+	LeaderboardComment []Glance
+
+	// LeaderboardPrefixBalance is ranked by the balance gained from transfers, because the untouched balance of a
+	// prefix only depends on its size. A transfer between two addresses in the same prefix counts once for each side in
+	// the transfer count of the prefix leaderboards.
+	LeaderboardPrefixBalance  []GlancePrefix
+	LeaderboardPrefixTransfer []GlancePrefix
 
-type LeaderboardGlance struct {
-	Address       netip.Addr `db:"address"`
-	BalanceDiff   int64      `db:"balance_diff"`
-	CommentCount  int64      `db:"comment_count"`
-	TransferCount int64      `db:"transfer_count"`
+	// NextOffset is nil when no section has more entries.
+	NextOffset *int
 }
 
-func RefreshLeaderboard(ctx context.Context, db dbConn, concurrently bool) (err error) {
-	//language=sql
-	query := `
-REFRESH MATERIALIZED VIEW CONCURRENTLY leaderboard_glance;
-`
-	if !concurrently {
-		query = `
-REFRESH MATERIALIZED VIEW leaderboard_glance;
-`
-	}
-	_, err = db.Exec(ctx, query)
-	if err != nil {
-		return fmt.Errorf("failed to refresh leaderboard: %w", err)
-	}
-	return nil
+type LeaderboardGlancePrefix struct {
+	Prefix        netip.Prefix `db:"prefix"`
+	BalanceDiff   int64        `db:"balance_diff"`
+	CommentCount  int64        `db:"comment_count"`
+	TransferCount int64        `db:"transfer_count"`
 }
 
 func GetLeaderboard(ctx context.Context, db dbConn, request GetLeaderboardRequest) (response GetLeaderboardResponse, untouchedBalance int64, err error) {
+	prefixIPv4 := request.PrefixIPv4
+	if prefixIPv4 == 0 {
+		prefixIPv4 = DefaultLeaderboardPrefixIPv4
+	}
+	prefixIPv6 := request.PrefixIPv6
+	if prefixIPv6 == 0 {
+		prefixIPv6 = DefaultLeaderboardPrefixIPv6
+	}
+	if prefixIPv4 < 0 || prefixIPv4 > 32 || prefixIPv6 < 0 || prefixIPv6 > 128 {
+		return response, 0, ErrPrefixInvalid
+	}
+	limit := request.Limit
+	if limit == 0 {
+		limit = leaderboardLimit
+	}
+	if limit < 0 || request.Offset < 0 || request.Offset > LeaderboardMaxOffset || request.Offset+limit > LeaderboardMaxOffset+LeaderboardMaxLimit {
+		return response, 0, ErrLeaderboardPage
+	}
+	// Every section reads one extra row to know whether there is another page.
+	page := fmt.Sprintf(" LIMIT %d OFFSET %d", limit+1, request.Offset)
+	more := false
 	batch := &pgx.Batch{}
 
 	untouchedBalance = BalanceUntouched(request.Now)
 
-	//language=sql
-	query := `
-SELECT address, balance_diff, comment_count, transfer_count
-FROM leaderboard_glance
-ORDER BY balance_diff DESC
-`
-	batch.Queue(query + fmt.Sprintf(" LIMIT %d", leaderboardLimit)).Query(func(rows pgx.Rows) error {
-		lGlances, err := pgx.CollectRows(rows, pgx.RowToStructByName[LeaderboardGlance])
+	addressGlances := func(rows pgx.Rows) ([]Glance, error) {
+		lGlances, err := pgx.CollectRows(rows, pgx.RowToStructByName[AddressStats])
 		if err != nil {
-			return fmt.Errorf("failed to collect leaderboard balance: %w", err)
+			return nil, err
+		}
+		if len(lGlances) > limit {
+			lGlances = lGlances[:limit]
+			more = true
 		}
-		response.LeaderboardBalance = make([]Glance, len(lGlances))
+		glances := make([]Glance, len(lGlances))
 		for i, g := range lGlances {
-			response.LeaderboardBalance[i] = Glance{
+			glances[i] = Glance{
 				Address:          g.Address,
 				BalanceAvailable: untouchedBalance + g.BalanceDiff,
 				CommentCount:     g.CommentCount,
 				TransferCount:    g.TransferCount,
 			}
 		}
+		return glances, nil
+	}
+
+	//language=sql
+	query := `
+SELECT address, balance_diff, comment_count, transfer_count
+FROM address_stats
+ORDER BY balance_diff DESC, address
+`
+	batch.Queue(query + page).Query(func(rows pgx.Rows) error {
+		response.LeaderboardBalance, err = addressGlances(rows)
+		if err != nil {
+			return fmt.Errorf("failed to collect leaderboard balance: %w", err)
+		}
 		return nil
 	})
 
 	//language=sql
 	query = `
 SELECT address, balance_diff, comment_count, transfer_count
-FROM leaderboard_glance
-ORDER BY transfer_count DESC
+FROM address_stats
+ORDER BY transfer_count DESC, address
 `
-	batch.Queue(query + fmt.Sprintf(" LIMIT %d", leaderboardLimit)).Query(func(rows pgx.Rows) error {
-		lGlances, err := pgx.CollectRows(rows, pgx.RowToStructByName[LeaderboardGlance])
+	batch.Queue(query + page).Query(func(rows pgx.Rows) error {
+		response.LeaderboardTransfer, err = addressGlances(rows)
 		if err != nil {
 			return fmt.Errorf("failed to collect leaderboard transfer: %w", err)
 		}
-		response.LeaderboardTransfer = make([]Glance, len(lGlances))
+		return nil
+	})
+
+	//language=sql
+	query = `
+SELECT address, balance_diff, comment_count, transfer_count
+FROM address_stats
+WHERE comment_count > 0
+ORDER BY comment_count DESC, address
+`
+	batch.Queue(query + page).Query(func(rows pgx.Rows) error {
+		response.LeaderboardComment, err = addressGlances(rows)
+		if err != nil {
+			return fmt.Errorf("failed to collect leaderboard comment: %w", err)
+		}
+		return nil
+	})
+
+	//language=sql
+	query = `
+SELECT network(set_masklen(address, CASE WHEN family(address) = 4 THEN $1::INT ELSE $2::INT END)) AS prefix,
+       SUM(balance_diff)::BIGINT                                                                AS balance_diff,
+       SUM(comment_count)::BIGINT                                                               AS comment_count,
+       SUM(transfer_count)::BIGINT                                                              AS transfer_count
+FROM address_stats
+GROUP BY 1
+`
+	prefixGlances := func(rows pgx.Rows) ([]GlancePrefix, error) {
+		lGlances, err := pgx.CollectRows(rows, pgx.RowToStructByName[LeaderboardGlancePrefix])
+		if err != nil {
+			return nil, err
+		}
+		if len(lGlances) > limit {
+			lGlances = lGlances[:limit]
+			more = true
+		}
+		glances := make([]GlancePrefix, len(lGlances))
 		for i, g := range lGlances {
-			response.LeaderboardTransfer[i] = Glance{
-				Address:          g.Address,
-				BalanceAvailable: untouchedBalance + g.BalanceDiff,
+			hostCount := PrefixHostCount(g.Prefix)
+			balance := new(big.Int).Mul(hostCount, big.NewInt(untouchedBalance))
+			glances[i] = GlancePrefix{
+				Prefix:           g.Prefix,
+				HostCount:        hostCount,
+				BalanceAvailable: balance.Add(balance, big.NewInt(g.BalanceDiff)),
 				CommentCount:     g.CommentCount,
 				TransferCount:    g.TransferCount,
 			}
 		}
+		return glances, nil
+	}
+	batch.Queue(query+" ORDER BY balance_diff DESC, prefix"+page, prefixIPv4, prefixIPv6).Query(func(rows pgx.Rows) error {
+		response.LeaderboardPrefixBalance, err = prefixGlances(rows)
+		if err != nil {
+			return fmt.Errorf("failed to collect prefix leaderboard balance: %w", err)
+		}
+		return nil
+	})
+	batch.Queue(query+" ORDER BY transfer_count DESC, prefix"+page, prefixIPv4, prefixIPv6).Query(func(rows pgx.Rows) error {
+		response.LeaderboardPrefixTransfer, err = prefixGlances(rows)
+		if err != nil {
+			return fmt.Errorf("failed to collect prefix leaderboard transfer: %w", err)
+		}
 		return nil
 	})
 
@@ -99,5 +201,40 @@ ORDER BY transfer_count DESC
 	if err != nil {
 		return response, 0, fmt.Errorf("failed to collect leaderboard: %w", err)
 	}
+	if more && request.Offset+limit <= LeaderboardMaxOffset {
+		next := request.Offset + limit
+		response.NextOffset = &next
+	}
 	return response, untouchedBalance, nil
 }
+
+// Page returns the entries of every section from offset to offset+limit with NextOffset set like GetLeaderboard would
+// set it. Zero uses the default limit. The response must have been read from offset zero with a limit greater than
+// offset+limit, or with the largest limit GetLeaderboard allows.
+func (r GetLeaderboardResponse) Page(offset, limit int) GetLeaderboardResponse {
+	if limit == 0 {
+		limit = leaderboardLimit
+	}
+	more := false
+	page := GetLeaderboardResponse{
+		LeaderboardBalance:        leaderboardPage(r.LeaderboardBalance, offset, limit, &more),
+		LeaderboardTransfer:       leaderboardPage(r.LeaderboardTransfer, offset, limit, &more),
+		LeaderboardComment:        leaderboardPage(r.LeaderboardComment, offset, limit, &more),
+		LeaderboardPrefixBalance:  leaderboardPage(r.LeaderboardPrefixBalance, offset, limit, &more),
+		LeaderboardPrefixTransfer: leaderboardPage(r.LeaderboardPrefixTransfer, offset, limit, &more),
+	}
+	if more && offset+limit <= LeaderboardMaxOffset {
+		next := offset + limit
+		page.NextOffset = &next
+	}
+	return page
+}
+
+func leaderboardPage[T any](entries []T, offset, limit int, more *bool) []T {
+	if len(entries) > offset+limit {
+		*more = true
+	}
+	start := min(offset, len(entries))
+	end := min(offset+limit, len(entries))
+	return entries[start:end:end]
+}
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
 	"net/netip"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -53,10 +56,6 @@ func TestLeaderboard(t *testing.T) {
 		}
 	}
 
-	err = RefreshLeaderboard(ctx, tx, false)
-	if err != nil {
-		t.Fatalf("Failed to refresh leaderboard.\n  Error: %s", err)
-	}
 	request := GetLeaderboardRequest{
 		Now: now,
 	}
@@ -126,10 +125,6 @@ func TestLeaderboard(t *testing.T) {
 		}
 	}
 
-	err = RefreshLeaderboard(ctx, tx, false)
-	if err != nil {
-		t.Fatalf("Failed to refresh leaderboard.\n  Error: %s", err)
-	}
 	request = GetLeaderboardRequest{
 		Now: now,
 	}
@@ -196,10 +191,6 @@ func TestLeaderboardEmpty(t *testing.T) {
 	}
 	defer tx.Rollback(ctx)
 
-	err = RefreshLeaderboard(ctx, tx, false)
-	if err != nil {
-		t.Fatalf("Failed to refresh leaderboard.\n  Error: %s", err)
-	}
 	response, _, err := GetLeaderboard(ctx, tx, GetLeaderboardRequest{})
 	if err != nil {
 		t.Fatalf("Failed to read leaderboard.\n  Error: %s", err)
@@ -211,3 +202,144 @@ func TestLeaderboardEmpty(t *testing.T) {
 		t.Fatalf("Transfer leaderboard should be empty but non-nil.")
 	}
 }
+
+func TestLeaderboard_Prefix(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	untouched := BalanceUntouched(now)
+	recipient := netip.MustParseAddr("203.0.113.5")
+	for i := range 3 {
+		tRequest := CreateTransferRequest{
+			Amount:    1_000,
+			Sender:    netip.MustParseAddr(fmt.Sprintf("198.51.100.%d", i+1)),
+			Now:       now,
+			Recipient: recipient,
+		}
+		_, err = CreateTransfer(ctx, tx, tRequest)
+		if err != nil {
+			t.Fatalf("Failed to transfer.\n  Error: %s", err)
+		}
+	}
+
+	request := GetLeaderboardRequest{
+		Now: now,
+	}
+	response, _, err := GetLeaderboard(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read leaderboard.\n  Error: %s", err)
+	}
+	expectedPrefix := netip.MustParsePrefix("203.0.113.0/24")
+	found := false
+	for _, entry := range response.LeaderboardPrefixBalance {
+		if entry.Prefix != expectedPrefix {
+			continue
+		}
+		found = true
+		expected := big.NewInt(256*untouched + 3_000)
+		if entry.BalanceAvailable.Cmp(expected) != 0 {
+			t.Fatalf("Prefix balance incorrect.\n  Expected: %s\n  Actual: %s", expected, entry.BalanceAvailable)
+		}
+		if entry.TransferCount != 3 {
+			t.Fatalf("Prefix transfer count should be 3 but is %d.", entry.TransferCount)
+		}
+	}
+	if !found {
+		t.Fatalf("Prefix %s should be on the prefix balance leaderboard.", expectedPrefix)
+	}
+
+	request.PrefixIPv4 = 16
+	response, _, err = GetLeaderboard(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read leaderboard.\n  Error: %s", err)
+	}
+	expectedPrefix = netip.MustParsePrefix("198.51.0.0/16")
+	if !slices.ContainsFunc(response.LeaderboardPrefixTransfer, func(g GlancePrefix) bool { return g.Prefix == expectedPrefix }) {
+		t.Fatalf("Prefix %s should be on the prefix transfer leaderboard.", expectedPrefix)
+	}
+}
+
+func TestLeaderboard_CommentPage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	addrs := []netip.Addr{
+		netip.MustParseAddr("192.168.16.1"),
+		netip.MustParseAddr("192.168.16.2"),
+		netip.MustParseAddr("192.168.16.3"),
+	}
+	for i, addr := range addrs {
+		for range 50 - i {
+			cRequest := CreateCommentRequest{
+				Addr:    addr,
+				Message: "test",
+				Now:     now,
+			}
+			_, err = CreateComment(ctx, tx, cRequest)
+			if err != nil {
+				t.Fatalf("Failed to write comment.\n  Error: %s", err)
+			}
+		}
+	}
+
+	request := GetLeaderboardRequest{
+		Now:   now,
+		Limit: 2,
+	}
+	response, _, err := GetLeaderboard(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read leaderboard.\n  Error: %s", err)
+	}
+	if len(response.LeaderboardComment) != 2 {
+		t.Fatalf("Comment leaderboard should have 2 entries but has %d.", len(response.LeaderboardComment))
+	}
+	for i, entry := range response.LeaderboardComment {
+		if entry.Address != addrs[i] || entry.CommentCount != int64(50-i) {
+			t.Fatalf("Comment leaderboard entry %d incorrect.\n  Address: %s\n  Comment count: %d", i, entry.Address, entry.CommentCount)
+		}
+	}
+	if response.NextOffset == nil || *response.NextOffset != 2 {
+		t.Fatalf("Next offset should be 2.")
+	}
+
+	request.Offset = *response.NextOffset
+	response, _, err = GetLeaderboard(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read leaderboard.\n  Error: %s", err)
+	}
+	if len(response.LeaderboardComment) == 0 || response.LeaderboardComment[0].Address != addrs[2] {
+		t.Fatalf("Second page of the comment leaderboard should start with %s.", addrs[2])
+	}
+
+	request.Limit = LeaderboardMaxOffset + LeaderboardMaxLimit
+	request.Offset = 0
+	all, _, err := GetLeaderboard(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to read leaderboard.\n  Error: %s", err)
+	}
+	paged := all.Page(2, 2)
+	if len(paged.LeaderboardComment) == 0 || paged.LeaderboardComment[0].Address != addrs[2] {
+		t.Fatalf("Page of the comment leaderboard should start with %s.", addrs[2])
+	}
+	if len(paged.LeaderboardBalance) != len(response.LeaderboardBalance) {
+		t.Fatalf("Page should match the leaderboard read with the same offset and limit.")
+	}
+
+	request.Offset = 1
+	_, _, err = GetLeaderboard(ctx, tx, request)
+	if !errors.Is(err, ErrLeaderboardPage) {
+		t.Fatalf("Reading past the maximum entries should fail.\n  Error: %s", err)
+	}
+}
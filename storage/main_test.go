@@ -28,5 +28,10 @@ func TestMain(m *testing.M) {
 	}
 	defer pool.Close()
 
+	_, err = MigrateUp(ctx, pool)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to migrate PostgreSQL.\n  Error: %s", err))
+	}
+
 	m.Run()
 }
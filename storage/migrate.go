@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockKey is the PostgreSQL advisory lock key held while migrations run so concurrent migrators, such as
+// parallel test packages or multiple replicas, do not interleave.
+const migrationLockKey = 0x6970636f696e // "ipcoin"
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration Migration
+	Applied   *time.Time
+}
+
+// Migrations returns the embedded migrations ordered by version. Migration files are named
+// NNNN_description.up.sql and NNNN_description.down.sql.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration file %q must end in .up.sql or .down.sql", name)
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, description, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration file %q must be named NNNN_description", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration version of %q: %w", name, err)
+		}
+		b, err := migrationFS.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", name, err)
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{
+				Version: version,
+				Name:    description,
+			}
+			byVersion[version] = m
+		}
+		if m.Name != description {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, m.Name, description)
+		}
+		if direction == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration version %d must have both an up and a down file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in a single transaction and returns the applied migrations.
+func MigrateUp(ctx context.Context, pool *pgxpool.Pool) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = withMigrationLock(ctx, pool, func(tx pgx.Tx) error {
+		versions, err := readMigrationVersions(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			_, ok := versions[m.Version]
+			if ok {
+				continue
+			}
+			_, err = tx.Exec(ctx, m.Up)
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d %q: %w", m.Version, m.Name, err)
+			}
+			//language=sql
+			query := `
+INSERT INTO schema_migrations (version, name, applied)
+VALUES ($1, $2, $3)
+`
+			_, err = tx.Exec(ctx, query, m.Version, m.Name, time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to record migration %d %q: %w", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts up to steps of the most recently applied migrations in a single transaction and returns the
+// reverted migrations.
+func MigrateDown(ctx context.Context, pool *pgxpool.Pool, steps int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = withMigrationLock(ctx, pool, func(tx pgx.Tx) error {
+		versions, err := readMigrationVersions(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			_, ok := versions[m.Version]
+			if !ok {
+				continue
+			}
+			_, err = tx.Exec(ctx, m.Down)
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d %q: %w", m.Version, m.Name, err)
+			}
+			//language=sql
+			query := `
+DELETE
+FROM schema_migrations
+WHERE version = $1
+`
+			_, err = tx.Exec(ctx, query, m.Version)
+			if err != nil {
+				return fmt.Errorf("failed to delete migration record %d %q: %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+// GetMigrationStatus reports every embedded migration and when it was applied, if it was.
+func GetMigrationStatus(ctx context.Context, db dbConn) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	versions, err := readMigrationVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i] = MigrationStatus{
+			Migration: m,
+		}
+		applied, ok := versions[m.Version]
+		if ok {
+			status[i].Applied = &applied
+		}
+	}
+	return status, nil
+}
+
+// CheckSchemaVersion confirms the database schema is exactly at the version this build of the code expects.
+func CheckSchemaVersion(ctx context.Context, db dbConn) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	versions, err := readMigrationVersions(ctx, db)
+	if err != nil {
+		return err
+	}
+	for version := range versions {
+		if !slices.ContainsFunc(migrations, func(m Migration) bool { return m.Version == version }) {
+			return fmt.Errorf("database has migration version %d applied: %w", version, ErrSchemaUnknown)
+		}
+	}
+	for _, m := range migrations {
+		_, ok := versions[m.Version]
+		if !ok {
+			return fmt.Errorf("database is missing migration version %d %q: %w", m.Version, m.Name, ErrSchemaOutdated)
+		}
+	}
+	return nil
+}
+
+func withMigrationLock(ctx context.Context, pool *pgxpool.Pool, f func(tx pgx.Tx) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", int64(migrationLockKey))
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	//language=sql
+	query := `
+CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version BIGINT PRIMARY KEY,
+    name    TEXT        NOT NULL,
+    applied TIMESTAMPTZ NOT NULL
+);
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	err = f(tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit migration transaction: %w", err)
+	}
+	return nil
+}
+
+func readMigrationVersions(ctx context.Context, db dbConn) (map[int64]time.Time, error) {
+	var exists bool
+	err := db.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for schema_migrations table: %w", err)
+	}
+	versions := make(map[int64]time.Time)
+	if !exists {
+		return versions, nil
+	}
+
+	//language=sql
+	query := `
+SELECT version, applied
+FROM schema_migrations
+`
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		var applied time.Time
+		err = rows.Scan(&version, &applied)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schema migration: %w", err)
+		}
+		versions[version] = applied
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate over schema migration rows: %w", err)
+	}
+	return versions, nil
+}
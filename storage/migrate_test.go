@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Failed to read migrations.\n  Error: %s", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatalf("There should be at least one migration.")
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Fatalf("Migration versions must be sequential.\n  Expected: %d\n  Actual: %d", i+1, m.Version)
+		}
+		if m.Name == "" {
+			t.Fatalf("Migration %d must have a name.", m.Version)
+		}
+	}
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := CheckSchemaVersion(ctx, pool)
+	if err != nil {
+		t.Fatalf("Schema should be at the latest version after migrating.\n  Error: %s", err)
+	}
+
+	status, err := GetMigrationStatus(ctx, pool)
+	if err != nil {
+		t.Fatalf("Failed to read migration status.\n  Error: %s", err)
+	}
+	for _, s := range status {
+		if s.Applied == nil {
+			t.Fatalf("Migration %d %q should be applied.", s.Migration.Version, s.Migration.Name)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+DROP MATERIALIZED VIEW IF EXISTS leaderboard_glance;
+DROP TABLE IF EXISTS comment_moderation;
+DROP TABLE IF EXISTS comment;
+DROP TABLE IF EXISTS transfer;
@@ -1,4 +1,6 @@
-CREATE TABLE transfer
+-- The initial schema matches the former startup.sql. IF NOT EXISTS and explicit index names let this migration adopt
+-- databases that were created by startup.sql before migrations existed.
+CREATE TABLE IF NOT EXISTS transfer
 (
     created   TIMESTAMPTZ NOT NULL,
     id        UUID PRIMARY KEY,
@@ -6,21 +8,21 @@ CREATE TABLE transfer
     recipient INET        NOT NULL,
     amount    BIGINT      NOT NULL
 );
-CREATE INDEX on transfer (sender);
-CREATE INDEX on transfer (recipient);
-CREATE INDEX on transfer (created DESC);
+CREATE INDEX IF NOT EXISTS transfer_sender_idx ON transfer (sender);
+CREATE INDEX IF NOT EXISTS transfer_recipient_idx ON transfer (recipient);
+CREATE INDEX IF NOT EXISTS transfer_created_idx ON transfer (created DESC);
 
-CREATE TABLE comment
+CREATE TABLE IF NOT EXISTS comment
 (
     created TIMESTAMPTZ NOT NULL,
     id      UUID PRIMARY KEY,
     address INET        NOT NULL,
     message TEXT        NOT NULL
 );
-CREATE INDEX on comment (address);
-CREATE INDEX on comment (created DESC);
+CREATE INDEX IF NOT EXISTS comment_address_idx ON comment (address);
+CREATE INDEX IF NOT EXISTS comment_created_idx ON comment (created DESC);
 
-CREATE TABLE comment_moderation
+CREATE TABLE IF NOT EXISTS comment_moderation
 (
     created    TIMESTAMPTZ NOT NULL,
     id         UUID PRIMARY KEY,
@@ -28,10 +30,10 @@ CREATE TABLE comment_moderation
     censored   BOOLEAN     NOT NULL DEFAULT FALSE,
     note       TEXT        NOT NULL
 );
-CREATE INDEX on comment_moderation (comment_id);
+CREATE INDEX IF NOT EXISTS comment_moderation_comment_id_idx ON comment_moderation (comment_id);
 
 CREATE
-MATERIALIZED VIEW leaderboard_glance AS
+MATERIALIZED VIEW IF NOT EXISTS leaderboard_glance AS
 WITH comments AS (SELECT address, COUNT(*) AS comment_count
                   FROM comment
                   GROUP BY address),
@@ -50,9 +52,7 @@ SELECT COALESCE(t.address, c.address) AS address,
        COALESCE(t.transfer_count, 0)  AS transfer_count
 FROM transfers t
          FULL OUTER JOIN comments c ON t.address = c.address; -- Change to a LEFT JOIN if performance becomes an issue.
-CREATE UNIQUE INDEX on leaderboard_glance (address);
-REFRESH
-MATERIALIZED VIEW CONCURRENTLY leaderboard_glance;
-CREATE INDEX on leaderboard_glance (balance_diff DESC, address ASC) WHERE balance_diff > 0;
-CREATE INDEX on leaderboard_glance (comment_count DESC, address ASC) WHERE comment_count > 0;
-CREATE INDEX on leaderboard_glance (transfer_count DESC, address ASC) WHERE transfer_count > 0;
+CREATE UNIQUE INDEX IF NOT EXISTS leaderboard_glance_address_idx ON leaderboard_glance (address);
+CREATE INDEX IF NOT EXISTS leaderboard_glance_balance_diff_address_idx ON leaderboard_glance (balance_diff DESC, address ASC) WHERE balance_diff > 0;
+CREATE INDEX IF NOT EXISTS leaderboard_glance_comment_count_address_idx ON leaderboard_glance (comment_count DESC, address ASC) WHERE comment_count > 0;
+CREATE INDEX IF NOT EXISTS leaderboard_glance_transfer_count_address_idx ON leaderboard_glance (transfer_count DESC, address ASC) WHERE transfer_count > 0;
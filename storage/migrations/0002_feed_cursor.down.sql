@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS comment_created_id_idx;
+CREATE INDEX comment_created_idx ON comment (created DESC);
+
+DROP INDEX IF EXISTS transfer_created_id_idx;
+CREATE INDEX transfer_created_idx ON transfer (created DESC);
@@ -0,0 +1,8 @@
+CREATE TABLE transfer_idempotency
+(
+    sender         INET   NOT NULL,
+    key            TEXT   NOT NULL,
+    transfer_id    UUID   NOT NULL REFERENCES transfer (id) ON DELETE CASCADE,
+    sender_balance BIGINT NOT NULL,
+    PRIMARY KEY (sender, key)
+);
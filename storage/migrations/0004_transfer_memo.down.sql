@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS transfer_moderation;
+
+ALTER TABLE transfer
+    DROP COLUMN IF EXISTS memo;
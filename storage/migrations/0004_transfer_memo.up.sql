@@ -0,0 +1,12 @@
+ALTER TABLE transfer
+    ADD COLUMN memo TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE transfer_moderation
+(
+    created     TIMESTAMPTZ NOT NULL,
+    id          UUID PRIMARY KEY,
+    transfer_id UUID        NOT NULL REFERENCES transfer (id) ON DELETE CASCADE,
+    censored    BOOLEAN     NOT NULL DEFAULT FALSE,
+    note        TEXT        NOT NULL
+);
+CREATE INDEX transfer_moderation_transfer_id_idx ON transfer_moderation (transfer_id);
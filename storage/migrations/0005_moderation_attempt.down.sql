@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transfer_moderation_attempt;
+DROP TABLE IF EXISTS comment_moderation_attempt;
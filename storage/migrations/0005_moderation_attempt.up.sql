@@ -0,0 +1,17 @@
+CREATE TABLE comment_moderation_attempt
+(
+    comment_id   UUID PRIMARY KEY REFERENCES comment (id) ON DELETE CASCADE,
+    attempts     INT         NOT NULL,
+    last_attempt TIMESTAMPTZ NOT NULL,
+    next_attempt TIMESTAMPTZ NOT NULL,
+    last_error   TEXT        NOT NULL
+);
+
+CREATE TABLE transfer_moderation_attempt
+(
+    transfer_id  UUID PRIMARY KEY REFERENCES transfer (id) ON DELETE CASCADE,
+    attempts     INT         NOT NULL,
+    last_attempt TIMESTAMPTZ NOT NULL,
+    next_attempt TIMESTAMPTZ NOT NULL,
+    last_error   TEXT        NOT NULL
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS comment_moderation_comment_id_seq_idx;
+ALTER TABLE comment_moderation
+    DROP COLUMN IF EXISTS reviewer;
+ALTER TABLE comment_moderation
+    DROP COLUMN IF EXISTS seq;
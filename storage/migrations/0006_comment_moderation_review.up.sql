@@ -0,0 +1,5 @@
+ALTER TABLE comment_moderation
+    ADD COLUMN seq BIGINT GENERATED ALWAYS AS IDENTITY;
+ALTER TABLE comment_moderation
+    ADD COLUMN reviewer TEXT NOT NULL DEFAULT '';
+CREATE INDEX comment_moderation_comment_id_seq_idx ON comment_moderation (comment_id, seq DESC);
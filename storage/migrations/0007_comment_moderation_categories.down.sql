@@ -0,0 +1,2 @@
+ALTER TABLE comment_moderation
+    DROP COLUMN IF EXISTS categories;
@@ -0,0 +1,2 @@
+ALTER TABLE comment_moderation
+    ADD COLUMN categories JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS comment_parent_transfer_id_idx;
+DROP INDEX IF EXISTS comment_parent_comment_id_idx;
+ALTER TABLE comment
+    DROP CONSTRAINT IF EXISTS comment_single_parent;
+ALTER TABLE comment
+    DROP COLUMN IF EXISTS parent_transfer_id;
+ALTER TABLE comment
+    DROP COLUMN IF EXISTS parent_comment_id;
@@ -0,0 +1,8 @@
+ALTER TABLE comment
+    ADD COLUMN parent_comment_id UUID REFERENCES comment (id) ON DELETE CASCADE;
+ALTER TABLE comment
+    ADD COLUMN parent_transfer_id UUID REFERENCES transfer (id) ON DELETE CASCADE;
+ALTER TABLE comment
+    ADD CONSTRAINT comment_single_parent CHECK (num_nonnulls(parent_comment_id, parent_transfer_id) <= 1);
+CREATE INDEX comment_parent_comment_id_idx ON comment (parent_comment_id) WHERE parent_comment_id IS NOT NULL;
+CREATE INDEX comment_parent_transfer_id_idx ON comment (parent_transfer_id) WHERE parent_transfer_id IS NOT NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE reaction
+(
+    created     TIMESTAMPTZ NOT NULL,
+    id          UUID PRIMARY KEY,
+    address     INET        NOT NULL,
+    comment_id  UUID REFERENCES comment (id) ON DELETE CASCADE,
+    transfer_id UUID REFERENCES transfer (id) ON DELETE CASCADE,
+    kind        TEXT        NOT NULL CHECK (kind IN ('thumbs_up', 'thumbs_down', 'heart', 'laugh', 'fire', 'coin')),
+    CHECK (num_nonnulls(comment_id, transfer_id) = 1)
+);
+CREATE UNIQUE INDEX reaction_comment_address_kind_idx ON reaction (comment_id, address, kind) WHERE comment_id IS NOT NULL;
+CREATE UNIQUE INDEX reaction_transfer_address_kind_idx ON reaction (transfer_id, address, kind) WHERE transfer_id IS NOT NULL;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS comment_revision;
+ALTER TABLE comment_moderation
+    DROP COLUMN IF EXISTS revision;
+ALTER TABLE comment
+    DROP COLUMN IF EXISTS deleted;
+ALTER TABLE comment
+    DROP COLUMN IF EXISTS edited;
+ALTER TABLE comment
+    DROP COLUMN IF EXISTS revision;
@@ -0,0 +1,18 @@
+ALTER TABLE comment
+    ADD COLUMN revision INT NOT NULL DEFAULT 0;
+ALTER TABLE comment
+    ADD COLUMN edited TIMESTAMPTZ;
+ALTER TABLE comment
+    ADD COLUMN deleted TIMESTAMPTZ;
+
+ALTER TABLE comment_moderation
+    ADD COLUMN revision INT NOT NULL DEFAULT 0;
+
+CREATE TABLE comment_revision
+(
+    created    TIMESTAMPTZ NOT NULL,
+    comment_id UUID        NOT NULL REFERENCES comment (id) ON DELETE CASCADE,
+    revision   INT         NOT NULL,
+    message    TEXT        NOT NULL,
+    PRIMARY KEY (comment_id, revision)
+);
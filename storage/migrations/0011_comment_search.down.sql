@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS comment_message_tsv_idx;
+ALTER TABLE comment
+    DROP COLUMN IF EXISTS message_tsv;
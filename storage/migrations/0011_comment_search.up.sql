@@ -0,0 +1,3 @@
+ALTER TABLE comment
+    ADD COLUMN message_tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', message)) STORED;
+CREATE INDEX comment_message_tsv_idx ON comment USING GIN (message_tsv);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS transfer_recipient_gist_idx;
+DROP INDEX IF EXISTS transfer_sender_gist_idx;
+DROP INDEX IF EXISTS comment_address_gist_idx;
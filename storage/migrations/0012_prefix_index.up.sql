@@ -0,0 +1,5 @@
+-- GiST indexes let prefix queries such as address <<= '192.0.2.0/24' use an index. The B-tree indexes still serve
+-- exact address lookups.
+CREATE INDEX comment_address_gist_idx ON comment USING GIST (address inet_ops);
+CREATE INDEX transfer_sender_gist_idx ON transfer USING GIST (sender inet_ops);
+CREATE INDEX transfer_recipient_gist_idx ON transfer USING GIST (recipient inet_ops);
@@ -0,0 +1 @@
+-- Intentionally empty. See the up migration.
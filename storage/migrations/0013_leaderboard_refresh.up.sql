@@ -0,0 +1 @@
+-- Intentionally empty. Version 13 is kept so databases that already applied it stay in sequence.
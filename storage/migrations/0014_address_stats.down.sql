@@ -0,0 +1,25 @@
+CREATE MATERIALIZED VIEW leaderboard_glance AS
+WITH comments AS (SELECT address, COUNT(*) AS comment_count
+                  FROM comment
+                  GROUP BY address),
+     transfers AS (SELECT address,
+                          COUNT(*)    AS transfer_count,
+                          SUM(amount) AS balance_diff
+                   FROM (SELECT recipient AS address, amount
+                         FROM transfer
+                         UNION ALL
+                         SELECT sender AS address, -amount
+                         FROM transfer) AS flat
+                   GROUP BY address)
+SELECT COALESCE(t.address, c.address) AS address,
+       COALESCE(t.balance_diff, 0)    AS balance_diff,
+       COALESCE(c.comment_count, 0)   AS comment_count,
+       COALESCE(t.transfer_count, 0)  AS transfer_count
+FROM transfers t
+         FULL OUTER JOIN comments c ON t.address = c.address;
+CREATE UNIQUE INDEX leaderboard_glance_address_idx ON leaderboard_glance (address);
+CREATE INDEX leaderboard_glance_balance_diff_address_idx ON leaderboard_glance (balance_diff DESC, address ASC) WHERE balance_diff > 0;
+CREATE INDEX leaderboard_glance_comment_count_address_idx ON leaderboard_glance (comment_count DESC, address ASC) WHERE comment_count > 0;
+CREATE INDEX leaderboard_glance_transfer_count_address_idx ON leaderboard_glance (transfer_count DESC, address ASC) WHERE transfer_count > 0;
+
+DROP TABLE IF EXISTS address_stats;
@@ -0,0 +1,36 @@
+-- address_stats replaces the leaderboard_glance materialized view. It is updated in the same transaction as each
+-- comment and transfer instead of being recomputed from every row.
+CREATE TABLE address_stats
+(
+    address        INET PRIMARY KEY,
+    balance_diff   BIGINT NOT NULL DEFAULT 0,
+    comment_count  BIGINT NOT NULL DEFAULT 0,
+    transfer_count BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX address_stats_balance_diff_address_idx ON address_stats (balance_diff DESC, address ASC);
+CREATE INDEX address_stats_comment_count_address_idx ON address_stats (comment_count DESC, address ASC) WHERE comment_count > 0;
+CREATE INDEX address_stats_transfer_count_address_idx ON address_stats (transfer_count DESC, address ASC);
+
+LOCK TABLE comment, transfer IN SHARE MODE;
+INSERT INTO address_stats (address, balance_diff, comment_count, transfer_count)
+WITH comments AS (SELECT address, COUNT(*) AS comment_count
+                  FROM comment
+                  GROUP BY address),
+     transfers AS (SELECT address,
+                          COUNT(*)    AS transfer_count,
+                          SUM(amount) AS balance_diff
+                   FROM (SELECT recipient AS address, amount
+                         FROM transfer
+                         UNION ALL
+                         SELECT sender AS address, -amount
+                         FROM transfer) AS flat
+                   GROUP BY address)
+SELECT COALESCE(t.address, c.address),
+       COALESCE(t.balance_diff, 0),
+       COALESCE(c.comment_count, 0),
+       COALESCE(t.transfer_count, 0)
+FROM transfers t
+         FULL OUTER JOIN comments c ON t.address = c.address;
+
+DROP TABLE IF EXISTS leaderboard_refresh;
+DROP MATERIALIZED VIEW leaderboard_glance;
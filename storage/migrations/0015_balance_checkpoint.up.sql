@@ -0,0 +1,10 @@
+-- A checkpoint is the balance diff of an address from every transfer created at or before as_of. Reading a past balance
+-- only sums the transfers after the latest checkpoint.
+CREATE TABLE balance_checkpoint
+(
+    address      INET        NOT NULL,
+    as_of        TIMESTAMPTZ NOT NULL,
+    balance_diff BIGINT      NOT NULL,
+    PRIMARY KEY (address, as_of)
+);
+CREATE INDEX balance_checkpoint_as_of_idx ON balance_checkpoint (as_of DESC);
@@ -0,0 +1,11 @@
+-- A token bucket per limiter and address shared by every server replica. tokens is negative while requests are waiting
+-- for tokens they have already reserved.
+CREATE TABLE rate_limit
+(
+    name    TEXT             NOT NULL,
+    address INET             NOT NULL,
+    tokens  DOUBLE PRECISION NOT NULL,
+    updated TIMESTAMPTZ      NOT NULL,
+    PRIMARY KEY (name, address)
+);
+CREATE INDEX rate_limit_updated_idx ON rate_limit (updated);
@@ -0,0 +1,9 @@
+-- Checkpoints without a sequence must only cover transfers dated before them, which these may not.
+DELETE
+FROM balance_checkpoint;
+ALTER TABLE balance_checkpoint
+    DROP COLUMN IF EXISTS seq;
+
+DROP INDEX IF EXISTS transfer_seq_idx;
+ALTER TABLE transfer
+    DROP COLUMN IF EXISTS seq;
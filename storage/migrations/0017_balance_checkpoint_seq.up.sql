@@ -0,0 +1,11 @@
+-- A transfer can commit long after the time it is dated, so checkpoints also record the transfer sequence they cover.
+-- A later transfer dated before a checkpoint has a larger sequence and is still summed after it. Checkpoints from
+-- before this migration cannot tell which transfers they covered and are deleted so the next run rebuilds them.
+ALTER TABLE transfer
+    ADD COLUMN seq BIGINT GENERATED ALWAYS AS IDENTITY;
+CREATE UNIQUE INDEX transfer_seq_idx ON transfer (seq);
+
+DELETE
+FROM balance_checkpoint;
+ALTER TABLE balance_checkpoint
+    ADD COLUMN seq BIGINT NOT NULL;
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModerationAttempt records that a batch was sent to a moderator. A failed attempt has a non-empty Error. The next
+// attempt for each row is delayed by Backoff doubled for every previous attempt, up to MaxBackoff.
+type ModerationAttempt struct {
+	Now        time.Time
+	Error      string
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+type ModerationAttemptStatus struct {
+	Attempts    int
+	LastAttempt time.Time
+	NextAttempt time.Time
+	LastError   string
+}
+
+// createModerationAttempt upserts attempt rows. The table and column are constants chosen by the caller.
+func createModerationAttempt(ctx context.Context, db dbConn, table, column string, ids []uuid.UUID, attempt ModerationAttempt) error {
+	//language=sql
+	query := fmt.Sprintf(`
+INSERT INTO %[1]s (%[2]s, attempts, last_attempt, next_attempt, last_error)
+SELECT id, 1, $2, $2 + make_interval(secs => $3), $5
+FROM unnest($1::UUID[]) AS id
+ON CONFLICT (%[2]s) DO UPDATE SET attempts     = %[1]s.attempts + 1,
+                                  last_attempt = excluded.last_attempt,
+                                  next_attempt = excluded.last_attempt +
+                                                 make_interval(secs => LEAST($3 * power(2, %[1]s.attempts), $4)),
+                                  last_error   = excluded.last_error
+`, table, column)
+	_, err := db.Exec(ctx, query, ids, attempt.Now, attempt.Backoff.Seconds(), attempt.MaxBackoff.Seconds(), attempt.Error)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// leaseModerationAttempt is the body of a common table expression that leases the rows selected by a claimed common
+// table expression. It moves their next attempt to $1 plus $3 seconds without counting an attempt. A new row counts
+// zero attempts so the backoff of the first real attempt is unchanged. The table and column are constants chosen by
+// the caller.
+func leaseModerationAttempt(table, column string) string {
+	//language=sql
+	return fmt.Sprintf(`
+INSERT INTO %[1]s (%[2]s, attempts, last_attempt, next_attempt, last_error)
+SELECT id, 0, $1, $1 + make_interval(secs => $3), ''
+FROM claimed
+ON CONFLICT (%[2]s) DO UPDATE SET next_attempt = excluded.next_attempt
+`, table, column)
+}
+
+func ReadCommentModerationAttempt(ctx context.Context, db dbConn, commentID uuid.UUID) (status ModerationAttemptStatus, err error) {
+	//language=sql
+	query := `
+SELECT attempts, last_attempt, next_attempt, last_error
+FROM comment_moderation_attempt
+WHERE comment_id = $1
+`
+	err = db.QueryRow(ctx, query, commentID).Scan(&status.Attempts, &status.LastAttempt, &status.NextAttempt, &status.LastError)
+	if err != nil {
+		return status, fmt.Errorf("failed to read comment moderation attempt: %w", err)
+	}
+	return status, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type TakeRateLimitTokenRequest struct {
+	Name    string
+	Address netip.Addr
+	Burst   int
+	// Rate is the number of tokens added per second.
+	Rate float64
+	// MaxWait is optional. A token that would not be available within MaxWait is not taken.
+	MaxWait *time.Duration
+}
+
+// TakeRateLimitToken takes one token from the bucket of the address and returns how long to wait until the token is
+// available. The bucket starts full, refills at Rate, and holds at most Burst tokens. It returns ErrRateLimitWait
+// without taking a token if the wait would be longer than MaxWait.
+func TakeRateLimitToken(ctx context.Context, db dbConn, request TakeRateLimitTokenRequest) (time.Duration, error) {
+	var maxWait *float64
+	if request.MaxWait != nil {
+		seconds := request.MaxWait.Seconds()
+		maxWait = &seconds
+	}
+
+	//language=sql
+	query := `
+INSERT INTO rate_limit (name, address, tokens, updated)
+VALUES ($1, $2, $3::DOUBLE PRECISION - 1, statement_timestamp())
+ON CONFLICT (name, address) DO UPDATE
+    SET tokens  = LEAST($3, rate_limit.tokens + EXTRACT(EPOCH FROM statement_timestamp() - rate_limit.updated) * $4::DOUBLE PRECISION) - 1,
+        updated = statement_timestamp()
+WHERE $5::DOUBLE PRECISION IS NULL
+   OR LEAST($3, rate_limit.tokens + EXTRACT(EPOCH FROM statement_timestamp() - rate_limit.updated) * $4) - 1 >= -$5 * $4
+RETURNING tokens
+`
+	var tokens float64
+	err := db.QueryRow(ctx, query, request.Name, request.Address, float64(request.Burst), request.Rate, maxWait).Scan(&tokens)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrRateLimitWait
+		}
+		return 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if tokens >= 0 {
+		return 0, nil
+	}
+	return time.Duration(-tokens / request.Rate * float64(time.Second)), nil
+}
+
+// DeleteRateLimitIdle deletes the buckets that have not been used for idle. Callers pick an idle duration long enough
+// for every bucket to have refilled so deleting it does not change its budget.
+func DeleteRateLimitIdle(ctx context.Context, db dbConn, idle time.Duration) (int64, error) {
+	//language=sql
+	query := `
+DELETE
+FROM rate_limit
+WHERE updated < statement_timestamp() - make_interval(secs => $1)
+`
+	tag, err := db.Exec(ctx, query, idle.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idle rate limits: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ReactionKind string
+
+// The allow-list of reactions. It must match the CHECK constraint on reaction.kind.
+const (
+	ReactionKindThumbsUp   ReactionKind = "thumbs_up"
+	ReactionKindThumbsDown ReactionKind = "thumbs_down"
+	ReactionKindHeart      ReactionKind = "heart"
+	ReactionKindLaugh      ReactionKind = "laugh"
+	ReactionKindFire       ReactionKind = "fire"
+	ReactionKindCoin       ReactionKind = "coin"
+)
+
+var ReactionKinds = []ReactionKind{
+	ReactionKindThumbsUp,
+	ReactionKindThumbsDown,
+	ReactionKindHeart,
+	ReactionKindLaugh,
+	ReactionKindFire,
+	ReactionKindCoin,
+}
+
+// ReactionTarget is a comment or a transfer. Exactly one must be set.
+type ReactionTarget struct {
+	CommentID  *uuid.UUID
+	TransferID *uuid.UUID
+}
+
+type ReactionRequest struct {
+	Address netip.Addr
+	Target  ReactionTarget
+	Kind    ReactionKind
+	Now     time.Time
+}
+
+type ReactionCount struct {
+	Kind  ReactionKind
+	Count int64
+}
+
+type ReadReactionCountsResponse struct {
+	Comment  map[uuid.UUID][]ReactionCount
+	Transfer map[uuid.UUID][]ReactionCount
+}
+
+func (r ReactionRequest) validate() error {
+	if (r.Target.CommentID == nil) == (r.Target.TransferID == nil) {
+		return ErrReactionTarget
+	}
+	if !slices.Contains(ReactionKinds, r.Kind) {
+		return ErrReactionKind
+	}
+	return nil
+}
+
+// CreateReaction reports false if the address already reacted to the target with the same kind. It returns
+// ErrReactionTargetNotFound if the target does not exist.
+func CreateReaction(ctx context.Context, db dbConn, request ReactionRequest) (created bool, err error) {
+	err = request.validate()
+	if err != nil {
+		return false, err
+	}
+	//language=sql
+	query := `
+INSERT INTO reaction (created, id, address, comment_id, transfer_id, kind)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT DO NOTHING
+`
+	tag, err := db.Exec(ctx, query, request.Now, uuid.New(), request.Address, request.Target.CommentID, request.Target.TransferID, request.Kind)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgCodeForeignKeyViolation {
+			return false, ErrReactionTargetNotFound
+		}
+		return false, fmt.Errorf("failed to insert reaction: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteReaction reports false if the address had not reacted to the target with the kind.
+func DeleteReaction(ctx context.Context, db dbConn, request ReactionRequest) (deleted bool, err error) {
+	err = request.validate()
+	if err != nil {
+		return false, err
+	}
+	//language=sql
+	query := `
+DELETE
+FROM reaction
+WHERE address = $1
+  AND (comment_id = $2::UUID OR transfer_id = $3::UUID)
+  AND kind = $4
+`
+	tag, err := db.Exec(ctx, query, request.Address, request.Target.CommentID, request.Target.TransferID, request.Kind)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete reaction: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReadReactionCounts returns the reaction counts per comment and per transfer. Targets without reactions are missing
+// from the maps.
+func ReadReactionCounts(ctx context.Context, db dbConn, commentIDs, transferIDs []uuid.UUID) (ReadReactionCountsResponse, error) {
+	//language=sql
+	query := `
+SELECT comment_id, transfer_id, kind, COUNT(*)
+FROM reaction
+WHERE comment_id = ANY ($1)
+   OR transfer_id = ANY ($2)
+GROUP BY comment_id, transfer_id, kind
+ORDER BY kind
+`
+	response := ReadReactionCountsResponse{
+		Comment:  make(map[uuid.UUID][]ReactionCount),
+		Transfer: make(map[uuid.UUID][]ReactionCount),
+	}
+	rows, err := db.Query(ctx, query, commentIDs, transferIDs)
+	if err != nil {
+		return response, fmt.Errorf("failed to read reaction counts: %w", err)
+	}
+	var commentID, transferID *uuid.UUID
+	var count ReactionCount
+	_, err = pgx.ForEachRow(rows, []any{&commentID, &transferID, &count.Kind, &count.Count}, func() error {
+		switch {
+		case commentID != nil:
+			response.Comment[*commentID] = append(response.Comment[*commentID], count)
+		case transferID != nil:
+			response.Transfer[*transferID] = append(response.Transfer[*transferID], count)
+		}
+		return nil
+	})
+	if err != nil {
+		return response, fmt.Errorf("failed to collect reaction counts: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	commentResponse, err := CreateComment(ctx, tx, CreateCommentRequest{
+		Addr:    netip.MustParseAddr("192.168.11.1"),
+		Message: "test",
+		Now:     now,
+	})
+	if err != nil {
+		t.Fatalf("Failed to write comment.\n  Error: %s", err)
+	}
+	commentID := commentResponse.Comment.ID
+
+	request := ReactionRequest{
+		Address: netip.MustParseAddr("192.168.11.2"),
+		Target:  ReactionTarget{CommentID: &commentID},
+		Kind:    ReactionKindHeart,
+		Now:     now,
+	}
+	for i, expected := range []bool{true, false} {
+		created, err := CreateReaction(ctx, tx, request)
+		if err != nil {
+			t.Fatalf("Failed to create reaction.\n  Error: %s", err)
+		}
+		if created != expected {
+			t.Fatalf("Unexpected created result for attempt %d.\n  Expected: %t\n  Actual: %t", i, expected, created)
+		}
+	}
+	other := request
+	other.Address = netip.MustParseAddr("192.168.11.3")
+	_, err = CreateReaction(ctx, tx, other)
+	if err != nil {
+		t.Fatalf("Failed to create reaction.\n  Error: %s", err)
+	}
+
+	counts, err := ReadReactionCounts(ctx, tx, []uuid.UUID{commentID}, nil)
+	if err != nil {
+		t.Fatalf("Failed to read reaction counts.\n  Error: %s", err)
+	}
+	c := counts.Comment[commentID]
+	if len(c) != 1 || c[0].Kind != ReactionKindHeart || c[0].Count != 2 {
+		t.Fatalf("Unexpected reaction counts.\n  Actual: %+v", c)
+	}
+
+	deleted, err := DeleteReaction(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to delete reaction.\n  Error: %s", err)
+	}
+	if !deleted {
+		t.Fatalf("Reaction should be deleted.")
+	}
+
+	invalid := request
+	invalid.Kind = "unknown"
+	_, err = CreateReaction(ctx, tx, invalid)
+	if !errors.Is(err, ErrReactionKind) {
+		t.Fatalf("Should have reaction kind error.\n  Error: %s", err)
+	}
+
+	missing := uuid.New()
+	request.Target = ReactionTarget{TransferID: &missing}
+	_, err = CreateReaction(ctx, tx, request)
+	if !errors.Is(err, ErrReactionTargetNotFound) {
+		t.Fatalf("Should have reaction target not found error.\n  Error: %s", err)
+	}
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	searchLimit = 50
+	// SearchMaxOffset bounds how deep SearchComments pages so a broad query cannot rank the whole table repeatedly.
+	SearchMaxOffset = 1_000
+)
+
+type SearchCommentsRequest struct {
+	// Query uses web search syntax, such as quoted phrases, "or", and a leading "-" to exclude a word.
+	Query string
+
+	Address       *netip.Addr
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// IncludeCensored includes comments whose latest verdict censored them.
+	IncludeCensored bool
+	Offset          int
+}
+
+type SearchCommentsResponse struct {
+	Results []SearchCommentsResult
+	// NextOffset is nil when there are no more results.
+	NextOffset *int
+}
+
+type SearchCommentsResult struct {
+	Comment Comment
+	Rank    float32
+}
+
+// SearchComments returns comments matching the query, best match first. Deleted comments are never returned.
+func SearchComments(ctx context.Context, db dbConn, request SearchCommentsRequest) (response SearchCommentsResponse, err error) {
+	if request.Offset < 0 || request.Offset > SearchMaxOffset {
+		return response, ErrSearchOffset
+	}
+	q := psql.Select("created, id, address, message, parent_comment_id, parent_transfer_id, revision, edited, deleted").
+		Column(sq.Expr("ts_rank(message_tsv, websearch_to_tsquery('english', ?)) AS rank", request.Query)).
+		From("comment").
+		Where("message_tsv @@ websearch_to_tsquery('english', ?)", request.Query).
+		Where("deleted IS NULL")
+	if request.Address != nil {
+		q = q.Where(sq.Eq{"address": request.Address})
+	}
+	if request.CreatedAfter != nil {
+		q = q.Where(sq.Gt{"created": request.CreatedAfter})
+	}
+	if request.CreatedBefore != nil {
+		q = q.Where(sq.Lt{"created": request.CreatedBefore})
+	}
+	if !request.IncludeCensored {
+		q = q.Where(`COALESCE((SELECT m.censored
+                  FROM comment_moderation m
+                  WHERE m.comment_id = comment.id
+                  ORDER BY m.seq DESC
+                  LIMIT 1), FALSE) IS FALSE`)
+	}
+	query, args, err := q.OrderBy("rank DESC", "created DESC", "id DESC").
+		Limit(searchLimit + 1).
+		Offset(uint64(request.Offset)).
+		ToSql()
+	if err != nil {
+		return response, fmt.Errorf("failed to build search SQL query: %w", err)
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return response, fmt.Errorf("failed to search comments: %w", err)
+	}
+	defer rows.Close()
+	response.Results = make([]SearchCommentsResult, 0)
+	for rows.Next() {
+		var r SearchCommentsResult
+		c := &r.Comment
+		err = rows.Scan(&c.Created, &c.ID, &c.Address, &c.Message, &c.ParentCommentID, &c.ParentTransferID, &c.Revision, &c.Edited, &c.Deleted, &r.Rank)
+		if err != nil {
+			return response, fmt.Errorf("failed to scan comment search results: %w", err)
+		}
+		response.Results = append(response.Results, r)
+	}
+	err = rows.Err()
+	if err != nil {
+		return response, fmt.Errorf("failed to iterate over comment search rows: %w", err)
+	}
+	if len(response.Results) > searchLimit {
+		response.Results = response.Results[:searchLimit]
+		next := request.Offset + searchLimit
+		if next <= SearchMaxOffset {
+			response.NextOffset = &next
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchComments(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	first := netip.MustParseAddr("192.168.12.1")
+	second := netip.MustParseAddr("192.168.12.2")
+	create := func(addr netip.Addr, message string) uuid.UUID {
+		response, err := CreateComment(ctx, tx, CreateCommentRequest{
+			Addr:    addr,
+			Message: message,
+			Now:     now,
+		})
+		if err != nil {
+			t.Fatalf("Failed to write comment.\n  Error: %s", err)
+		}
+		return response.Comment.ID
+	}
+	visible := create(first, "the zebrafish swam upstream")
+	censored := create(first, "zebrafish are swimming everywhere")
+	other := create(second, "a zebrafish from another address")
+	create(first, "nothing to see here")
+
+	err = CreateCommentModeration(ctx, tx, []CommentModeration{{
+		CommentID: censored,
+		Censored:  true,
+		Note:      "test",
+	}}, now)
+	if err != nil {
+		t.Fatalf("Failed to create comment moderation.\n  Error: %s", err)
+	}
+
+	ids := func(response SearchCommentsResponse) []uuid.UUID {
+		found := make([]uuid.UUID, len(response.Results))
+		for i, r := range response.Results {
+			found[i] = r.Comment.ID
+		}
+		return found
+	}
+
+	response, err := SearchComments(ctx, tx, SearchCommentsRequest{Query: "zebrafish"})
+	if err != nil {
+		t.Fatalf("Failed to search comments.\n  Error: %s", err)
+	}
+	found := ids(response)
+	if !slices.Contains(found, visible) || !slices.Contains(found, other) {
+		t.Fatalf("Matching comments should be found.")
+	}
+	if slices.Contains(found, censored) {
+		t.Fatalf("Censored comment should be excluded by default.")
+	}
+
+	response, err = SearchComments(ctx, tx, SearchCommentsRequest{
+		Query:           "zebrafish",
+		Address:         &first,
+		IncludeCensored: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to search comments.\n  Error: %s", err)
+	}
+	found = ids(response)
+	if len(found) != 2 || !slices.Contains(found, visible) || !slices.Contains(found, censored) {
+		t.Fatalf("Search should only include comments from the address, including censored ones.\n  Found: %v", found)
+	}
+
+	_, err = SearchComments(ctx, tx, SearchCommentsRequest{Query: "zebrafish", Offset: SearchMaxOffset + 1})
+	if err == nil {
+		t.Fatalf("Offset past the maximum should fail.")
+	}
+}
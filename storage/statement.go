@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StatementDirection string
+
+const (
+	StatementDirectionReceived StatementDirection = "received"
+	StatementDirectionSent     StatementDirection = "sent"
+)
+
+type StatementEntry struct {
+	Created      time.Time
+	TransferID   uuid.UUID
+	Direction    StatementDirection
+	Counterparty netip.Addr
+	Amount       int64
+	Balance      int64
+	Memo         string
+	MemoCensored bool
+}
+
+// StatementCursor continues a statement after the last entry of a previous page.
+type StatementCursor struct {
+	Created    time.Time
+	TransferID uuid.UUID
+	// BalanceDiff is the balance diff after the last entry so balances continue across pages.
+	BalanceDiff int64
+}
+
+type ReadStatementRequest struct {
+	Address netip.Addr
+	// After is optional. Nil starts at the first transfer.
+	After *StatementCursor
+	Limit int
+}
+
+// ReadStatement returns up to Limit transfers touching the address in chronological order and the cursor of the next
+// page, which is nil after the last page. The balance of each entry is the balance immediately after the transfer,
+// including hourly accrual. Censored memos keep their text and set MemoCensored, like the feed. Each page is short enough to read in its own transaction so a
+// slow reader does not hold a snapshot open for the whole statement.
+func ReadStatement(ctx context.Context, db dbConn, request ReadStatementRequest) ([]StatementEntry, *StatementCursor, error) {
+	var afterCreated *time.Time
+	var afterID *uuid.UUID
+	var balanceDiff int64
+	if request.After != nil {
+		afterCreated = &request.After.Created
+		afterID = &request.After.TransferID
+		balanceDiff = request.After.BalanceDiff
+	}
+
+	// One extra row is read to know whether there is another page.
+	//language=sql
+	query := `
+SELECT t.created,
+       t.id,
+       t.sender,
+       t.recipient,
+       t.amount,
+       t.memo,
+       EXISTS (SELECT 1 FROM transfer_moderation m WHERE m.transfer_id = t.id AND m.censored IS TRUE)
+FROM transfer t
+WHERE (t.sender = $1 OR t.recipient = $1)
+  AND ($2::TIMESTAMPTZ IS NULL OR (t.created, t.id) > ($2, $3::UUID))
+ORDER BY t.created, t.id
+LIMIT $4
+`
+	rows, err := db.Query(ctx, query, request.Address, afterCreated, afterID, request.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read statement transfers: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]StatementEntry, 0, request.Limit)
+	more := false
+	for rows.Next() {
+		if len(entries) == request.Limit {
+			more = true
+			break
+		}
+		var t Transfer
+		var memoCensored bool
+		err = rows.Scan(&t.Created, &t.ID, &t.Sender, &t.Recipient, &t.Amount, &t.Memo, &memoCensored)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan statement transfer: %w", err)
+		}
+		entry := StatementEntry{
+			Created:      t.Created,
+			TransferID:   t.ID,
+			Amount:       t.Amount,
+			Memo:         t.Memo,
+			MemoCensored: memoCensored,
+		}
+		if t.Sender == request.Address {
+			entry.Direction = StatementDirectionSent
+			entry.Counterparty = t.Recipient
+			balanceDiff -= t.Amount
+		} else {
+			entry.Direction = StatementDirectionReceived
+			entry.Counterparty = t.Sender
+			balanceDiff += t.Amount
+		}
+		entry.Balance = BalanceUntouched(t.Created) + balanceDiff
+		entries = append(entries, entry)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate over statement transfer rows: %w", err)
+	}
+	if !more {
+		return entries, nil, nil
+	}
+	last := entries[len(entries)-1]
+	next := &StatementCursor{
+		Created:     last.Created,
+		TransferID:  last.TransferID,
+		BalanceDiff: balanceDiff,
+	}
+	return entries, next, nil
+}
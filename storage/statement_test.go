@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestReadStatement(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ipA := netip.MustParseAddr("192.168.0.1")
+	ipB := netip.MustParseAddr("192.168.0.2")
+	ipC := netip.MustParseAddr("192.168.0.3")
+	first := now.Add(-2 * time.Hour)
+	second := now.Add(-time.Hour)
+
+	tRequest := CreateTransferRequest{
+		Amount:    5,
+		Sender:    ipA,
+		Now:       first,
+		Recipient: ipB,
+	}
+	_, err = CreateTransfer(ctx, tx, tRequest)
+	if err != nil {
+		t.Fatalf("Failed to transfer.\n  Error: %s", err)
+	}
+	tRequest = CreateTransferRequest{
+		Amount:    3,
+		Sender:    ipC,
+		Now:       second,
+		Recipient: ipA,
+	}
+	_, err = CreateTransfer(ctx, tx, tRequest)
+	if err != nil {
+		t.Fatalf("Failed to transfer.\n  Error: %s", err)
+	}
+
+	// Read one entry per page so the balance has to continue across the cursor.
+	entries := make([]StatementEntry, 0)
+	request := ReadStatementRequest{
+		Address: ipA,
+		Limit:   1,
+	}
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatalf("Statement of 2 entries should fit in 2 pages.")
+		}
+		page, next, err := ReadStatement(ctx, tx, request)
+		if err != nil {
+			t.Fatalf("Failed to read statement.\n  Error: %s", err)
+		}
+		entries = append(entries, page...)
+		if next == nil {
+			break
+		}
+		request.After = next
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Statement should have 2 entries but has %d.", len(entries))
+	}
+	expected := []StatementEntry{
+		{
+			Direction:    StatementDirectionSent,
+			Counterparty: ipB,
+			Amount:       5,
+			Balance:      BalanceUntouched(first) - 5,
+		},
+		{
+			Direction:    StatementDirectionReceived,
+			Counterparty: ipC,
+			Amount:       3,
+			Balance:      BalanceUntouched(second) - 2,
+		},
+	}
+	for i, entry := range entries {
+		if entry.Direction != expected[i].Direction {
+			t.Fatalf("Statement entry %d has unexpected direction.\n  Expected: %s\n  Actual: %s", i, expected[i].Direction, entry.Direction)
+		}
+		if entry.Counterparty != expected[i].Counterparty {
+			t.Fatalf("Statement entry %d has unexpected counterparty.\n  Expected: %s\n  Actual: %s", i, expected[i].Counterparty, entry.Counterparty)
+		}
+		if entry.Amount != expected[i].Amount {
+			t.Fatalf("Statement entry %d has unexpected amount.\n  Expected: %d\n  Actual: %d", i, expected[i].Amount, entry.Amount)
+		}
+		if entry.Balance != expected[i].Balance {
+			t.Fatalf("Statement entry %d has unexpected balance.\n  Expected: %d\n  Actual: %d", i, expected[i].Balance, entry.Balance)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// threadDepthLimit bounds how deep ReadThread follows replies to replies.
+	threadDepthLimit = 32
+	// threadLimit bounds how many replies ReadThread returns.
+	threadLimit = 1_000
+)
+
+// ReadThreadRequest starts a thread at a comment or at a transfer. Exactly one must be set.
+type ReadThreadRequest struct {
+	CommentID  *uuid.UUID
+	TransferID *uuid.UUID
+}
+
+// ReadThread returns every reply below the root, oldest first. Replies are flat. Their parent references describe the
+// tree.
+func ReadThread(ctx context.Context, db dbConn, request ReadThreadRequest) ([]Comment, error) {
+	if (request.CommentID == nil) == (request.TransferID == nil) {
+		return nil, errors.New("exactly one thread root must be set")
+	}
+	//language=sql
+	query := `
+WITH RECURSIVE thread AS (SELECT created, id, address, message, parent_comment_id, parent_transfer_id, revision, edited,
+                                 deleted, 1 AS depth
+                          FROM comment
+                          WHERE parent_comment_id = $1::UUID
+                             OR parent_transfer_id = $2::UUID
+                          UNION ALL
+                          SELECT c.created, c.id, c.address, c.message, c.parent_comment_id, c.parent_transfer_id,
+                                 c.revision, c.edited, c.deleted, t.depth + 1
+                          FROM comment c
+                                   JOIN thread t ON c.parent_comment_id = t.id
+                          WHERE t.depth < $3)
+SELECT created, id, address, message, parent_comment_id, parent_transfer_id, revision, edited, deleted
+FROM thread
+ORDER BY created, id
+LIMIT $4
+`
+	rows, err := db.Query(ctx, query, request.CommentID, request.TransferID, threadDepthLimit, threadLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read thread: %w", err)
+	}
+	replies, err := pgx.CollectRows(rows, pgx.RowToStructByName[Comment])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect thread: %w", err)
+	}
+	return replies, nil
+}
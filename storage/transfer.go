@@ -2,19 +2,27 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/netip"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// transferSenderLockClass namespaces the per-sender advisory locks. The two-key form of the lock functions does not
+// overlap with the single-key locks such as migrationLockKey.
+const transferSenderLockClass int32 = 0x69706374 // "ipct"
+
 type Transfer struct {
 	Created   time.Time  `db:"created"`
 	ID        uuid.UUID  `db:"id"`
 	Sender    netip.Addr `db:"sender"`
 	Recipient netip.Addr `db:"recipient"`
 	Amount    int64      `db:"amount"`
+	Memo      string     `db:"memo"`
 }
 
 type CreateTransferRequest struct {
@@ -22,6 +30,11 @@ type CreateTransferRequest struct {
 	Sender    netip.Addr
 	Now       time.Time
 	Recipient netip.Addr
+	Memo      string
+
+	// IdempotencyKey is optional. A request with the same sender and key as a previous transfer returns the previous
+	// response instead of transferring again.
+	IdempotencyKey string
 }
 
 type CreateTransferResponse struct {
@@ -29,7 +42,24 @@ type CreateTransferResponse struct {
 	SenderBalance int64
 }
 
+// CreateTransfer must be called in a transaction. It locks the sender until the transaction ends so concurrent transfers
+// from the same sender cannot spend the same balance, even from different processes.
 func CreateTransfer(ctx context.Context, db dbConn, request CreateTransferRequest) (CreateTransferResponse, error) {
+	err := lockTransferSender(ctx, db, request.Sender)
+	if err != nil {
+		return CreateTransferResponse{}, err
+	}
+
+	if request.IdempotencyKey != "" {
+		response, found, err := readTransferIdempotent(ctx, db, request)
+		if err != nil {
+			return CreateTransferResponse{}, err
+		}
+		if found {
+			return response, nil
+		}
+	}
+
 	checkBalanceRequest := GetBalanceRequest{
 		Address: request.Sender,
 		Now:     request.Now,
@@ -45,15 +75,31 @@ func CreateTransfer(ctx context.Context, db dbConn, request CreateTransferReques
 	}
 
 	query := `
-INSERT INTO transfer (created, id, sender, recipient, amount)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO transfer (created, id, sender, recipient, amount, memo)
+VALUES ($1, $2, $3, $4, $5, $6)
 `
 	id := uuid.New()
-	_, err = db.Exec(ctx, query, request.Now, id, request.Sender, request.Recipient, request.Amount)
+	_, err = db.Exec(ctx, query, request.Now, id, request.Sender, request.Recipient, request.Amount, request.Memo)
 	if err != nil {
 		return CreateTransferResponse{}, fmt.Errorf("failed to insert new transfer: %w", err)
 	}
 
+	if request.IdempotencyKey != "" {
+		//language=sql
+		query = `
+INSERT INTO transfer_idempotency (sender, key, transfer_id, sender_balance)
+VALUES ($1, $2, $3, $4)
+`
+		_, err = db.Exec(ctx, query, request.Sender, request.IdempotencyKey, id, balance)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgCodeUniqueViolation {
+				return CreateTransferResponse{}, fmt.Errorf("failed to insert transfer idempotency key: %w", ErrIdempotencyKeyInUse)
+			}
+			return CreateTransferResponse{}, fmt.Errorf("failed to insert transfer idempotency key: %w", err)
+		}
+	}
+
 	response := CreateTransferResponse{
 		Transfer: Transfer{
 			Created:   request.Now,
@@ -61,8 +107,76 @@ VALUES ($1, $2, $3, $4, $5)
 			Sender:    request.Sender,
 			Recipient: request.Recipient,
 			Amount:    request.Amount,
+			Memo:      request.Memo,
 		},
 		SenderBalance: balance,
 	}
+	err = updateAddressStats(ctx, db, transferAddressStats(response.Transfer)...)
+	if err != nil {
+		return CreateTransferResponse{}, err
+	}
+	err = notifyFeed(ctx, db, FeedEvent{Transfer: &response.Transfer})
+	if err != nil {
+		return CreateTransferResponse{}, err
+	}
 	return response, nil
 }
+
+// lockTransferSender blocks until no other transaction holds the transfer lock of the sender. The lock is released when
+// the transaction ends. The balance must be read after the lock is acquired.
+func lockTransferSender(ctx context.Context, db dbConn, sender netip.Addr) error {
+	//language=sql
+	query := `
+SELECT pg_advisory_xact_lock($1, hashtext(host($2)))
+`
+	_, err := db.Exec(ctx, query, transferSenderLockClass, sender)
+	if err != nil {
+		return fmt.Errorf("failed to lock transfer sender: %w", err)
+	}
+	return nil
+}
+
+func readTransferIdempotent(ctx context.Context, db dbConn, request CreateTransferRequest) (response CreateTransferResponse, found bool, err error) {
+	//language=sql
+	query := `
+SELECT t.created, t.id, t.sender, t.recipient, t.amount, t.memo, i.sender_balance
+FROM transfer_idempotency i
+         JOIN transfer t ON t.id = i.transfer_id
+WHERE i.sender = $1
+  AND i.key = $2
+`
+	t := &response.Transfer
+	err = db.QueryRow(ctx, query, request.Sender, request.IdempotencyKey).Scan(&t.Created, &t.ID, &t.Sender, &t.Recipient, &t.Amount, &t.Memo, &response.SenderBalance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CreateTransferResponse{}, false, nil
+	}
+	if err != nil {
+		return CreateTransferResponse{}, false, fmt.Errorf("failed to read transfer by idempotency key: %w", err)
+	}
+	if t.Recipient != request.Recipient || t.Amount != request.Amount || t.Memo != request.Memo {
+		return CreateTransferResponse{}, false, fmt.Errorf("cannot replay transfer: %w", ErrIdempotencyKeyReuse)
+	}
+	return response, true, nil
+}
+
+// ReadTransfer returns ErrTransferNotFound if the transfer does not exist.
+func ReadTransfer(ctx context.Context, db dbConn, transferID uuid.UUID) (Transfer, error) {
+	//language=sql
+	query := `
+SELECT created, id, sender, recipient, amount, memo
+FROM transfer
+WHERE id = $1
+`
+	rows, err := db.Query(ctx, query, transferID)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("failed to read transfer: %w", err)
+	}
+	t, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[Transfer])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Transfer{}, ErrTransferNotFound
+		}
+		return Transfer{}, fmt.Errorf("failed to collect transfer: %w", err)
+	}
+	return t, nil
+}
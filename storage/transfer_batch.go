@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type CreateTransferBatchEntry struct {
+	Amount    int64
+	Recipient netip.Addr
+	Memo      string
+}
+
+type CreateTransferBatchRequest struct {
+	Entries []CreateTransferBatchEntry
+	Sender  netip.Addr
+	Now     time.Time
+}
+
+type CreateTransferBatchResponse struct {
+	Transfers     []Transfer
+	SenderBalance int64
+}
+
+// CreateTransferBatch checks the sender balance against the total of all entries once and then inserts every
+// transfer. The caller's transaction makes the batch succeed or fail as a unit. Like CreateTransfer, it locks the sender
+// until the transaction ends.
+func CreateTransferBatch(ctx context.Context, db dbConn, request CreateTransferBatchRequest) (CreateTransferBatchResponse, error) {
+	var total int64
+	for _, entry := range request.Entries {
+		if entry.Amount > math.MaxInt64-total {
+			return CreateTransferBatchResponse{}, fmt.Errorf("batch total overflows: %w", ErrInsufficientBalance)
+		}
+		total += entry.Amount
+	}
+
+	err := lockTransferSender(ctx, db, request.Sender)
+	if err != nil {
+		return CreateTransferBatchResponse{}, err
+	}
+	checkBalanceRequest := GetBalanceRequest{
+		Address: request.Sender,
+		Now:     request.Now,
+	}
+	balance, err := GetBalance(ctx, db, checkBalanceRequest)
+	if err != nil {
+		return CreateTransferBatchResponse{}, fmt.Errorf("failed to check balance for transfer batch: %w", err)
+	}
+
+	balance -= total
+	if balance < 0 {
+		return CreateTransferBatchResponse{}, fmt.Errorf("cannot complete transfer batch: %w", ErrInsufficientBalance)
+	}
+
+	//language=sql
+	query := `
+INSERT INTO transfer (created, id, sender, recipient, amount, memo)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+	batch := &pgx.Batch{}
+	response := CreateTransferBatchResponse{
+		Transfers:     make([]Transfer, len(request.Entries)),
+		SenderBalance: balance,
+	}
+	stats := make([]AddressStats, 0, 2*len(request.Entries))
+	for i, entry := range request.Entries {
+		t := Transfer{
+			Created:   request.Now,
+			ID:        uuid.New(),
+			Sender:    request.Sender,
+			Recipient: entry.Recipient,
+			Amount:    entry.Amount,
+			Memo:      entry.Memo,
+		}
+		batch.Queue(query, t.Created, t.ID, t.Sender, t.Recipient, t.Amount, t.Memo)
+		err = queueNotifyFeed(batch, FeedEvent{Transfer: &t})
+		if err != nil {
+			return CreateTransferBatchResponse{}, err
+		}
+		response.Transfers[i] = t
+		stats = append(stats, transferAddressStats(t)...)
+	}
+	queueAddressStats(batch, stats...)
+	err = db.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return CreateTransferBatchResponse{}, fmt.Errorf("failed to insert transfer batch: %w", err)
+	}
+	return response, nil
+}
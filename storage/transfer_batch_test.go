@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCreateTransferBatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sender := netip.MustParseAddr("192.168.0.1")
+	addr2 := netip.MustParseAddr("192.168.0.2")
+	addr3 := netip.MustParseAddr("192.168.0.3")
+
+	request := CreateTransferBatchRequest{
+		Entries: []CreateTransferBatchEntry{
+			{Amount: 1, Recipient: addr2},
+			{Amount: 2, Recipient: addr3},
+			{Amount: 3, Recipient: addr2},
+		},
+		Sender: sender,
+		Now:    now,
+	}
+	response, err := CreateTransferBatch(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to transfer batch.\n  Error: %s", err)
+	}
+	if len(response.Transfers) != len(request.Entries) {
+		t.Fatalf("Batch should have %d transfers but has %d.", len(request.Entries), len(response.Transfers))
+	}
+	untouched := BalanceUntouched(now)
+	if response.SenderBalance != untouched-6 {
+		t.Fatalf("Sender balance does not match expected balance.\n  Expected: %d\n  Actual: %d", untouched-6, response.SenderBalance)
+	}
+	for addr, expected := range map[netip.Addr]int64{sender: untouched - 6, addr2: untouched + 4, addr3: untouched + 2} {
+		balance, err := GetBalance(ctx, tx, GetBalanceRequest{Address: addr, Now: now})
+		if err != nil {
+			t.Fatalf("Failed to read balance.\n  Error: %s", err)
+		}
+		if balance != expected {
+			t.Fatalf("Balance does not match expected balance.\n  Address: %s\n  Expected: %d\n  Actual: %d", addr, expected, balance)
+		}
+	}
+
+	request = CreateTransferBatchRequest{
+		Entries: []CreateTransferBatchEntry{
+			{Amount: 1, Recipient: addr2},
+			{Amount: untouched, Recipient: addr3},
+		},
+		Sender: sender,
+		Now:    now,
+	}
+	_, err = CreateTransferBatch(ctx, tx, request)
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("Should have failed transfer batch with insufficient balance.\n  Error: %s", err)
+	}
+	balance, err := GetBalance(ctx, tx, GetBalanceRequest{Address: addr2, Now: now})
+	if err != nil {
+		t.Fatalf("Failed to read balance.\n  Error: %s", err)
+	}
+	if balance != untouched+4 {
+		t.Fatalf("Failed batch should not transfer anything.\n  Expected: %d\n  Actual: %d", untouched+4, balance)
+	}
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type TransferModeration struct {
+	Created    time.Time
+	ID         uuid.UUID
+	TransferID uuid.UUID
+	Censored   bool
+	Note       string
+}
+
+func CreateTransferModeration(ctx context.Context, db dbConn, moderations []TransferModeration, now time.Time) error {
+	batch := &pgx.Batch{}
+	//language=sql
+	query := `
+INSERT INTO transfer_moderation (created, id, transfer_id, censored, note)
+VALUES ($1, $2, $3, $4, $5)
+`
+	for _, m := range moderations {
+		u := uuid.New()
+		batch.Queue(query, now, u, m.TransferID, m.Censored, m.Note)
+	}
+	err := db.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return fmt.Errorf("failed to create transfer moderation: %w", err)
+	}
+	return nil
+}
+
+// ClaimTransferMemoUnmoderated claims up to limit transfers with an unmoderated memo whose next attempt is due. It
+// leases them like ClaimCommentUnmoderated.
+func ClaimTransferMemoUnmoderated(ctx context.Context, db dbConn, now time.Time, lease time.Duration, limit int) ([]Transfer, error) {
+	//language=sql
+	query := `
+WITH claimed AS (SELECT t.created, t.id, t.sender, t.recipient, t.amount, t.memo
+                 FROM transfer t
+                          LEFT JOIN transfer_moderation_attempt a ON t.id = a.transfer_id
+                 WHERE t.memo <> ''
+                   AND NOT EXISTS (SELECT 1 FROM transfer_moderation m WHERE m.transfer_id = t.id)
+                   AND (a.transfer_id IS NULL OR a.next_attempt <= $1)
+                 ORDER BY t.created
+                 LIMIT $2 FOR NO KEY UPDATE OF t SKIP LOCKED),
+     leased AS (` + leaseModerationAttempt("transfer_moderation_attempt", "transfer_id") + `)
+SELECT created, id, sender, recipient, amount, memo
+FROM claimed
+ORDER BY created
+`
+	rows, err := db.Query(ctx, query, now, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim unmoderated transfer memos: %w", err)
+	}
+	unmoderated, err := pgx.CollectRows(rows, pgx.RowToStructByPos[Transfer])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect unmoderated transfer memos: %w", err)
+	}
+	return unmoderated, nil
+}
+
+func CreateTransferModerationAttempt(ctx context.Context, db dbConn, transferIDs []uuid.UUID, attempt ModerationAttempt) error {
+	err := createModerationAttempt(ctx, db, "transfer_moderation_attempt", "transfer_id", transferIDs, attempt)
+	if err != nil {
+		return fmt.Errorf("failed to create transfer moderation attempt: %w", err)
+	}
+	return nil
+}
+
+func ReadTransferMemoCensored(ctx context.Context, db dbConn, transferIDs []uuid.UUID) ([]uuid.UUID, error) {
+	//language=sql
+	query := `
+SELECT DISTINCT transfer_id
+FROM transfer_moderation
+WHERE transfer_id = ANY ($1)
+  AND censored IS TRUE
+`
+	rows, err := db.Query(ctx, query, transferIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transfer moderation: %w", err)
+	}
+	censored, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect transfer moderation: %w", err)
+	}
+	return censored, nil
+}
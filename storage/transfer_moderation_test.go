@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTransferMemoModeration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sender := netip.MustParseAddr("192.168.8.1")
+	recipient := netip.MustParseAddr("192.168.8.2")
+	const memo = "thanks for lunch"
+	request := CreateTransferRequest{
+		Amount:    1,
+		Sender:    sender,
+		Now:       now,
+		Recipient: recipient,
+		Memo:      memo,
+	}
+	response, err := CreateTransfer(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to transfer.\n  Error: %s", err)
+	}
+	if response.Transfer.Memo != memo {
+		t.Fatalf("Memo does not match.\n  Expected: %q\n  Actual: %q", memo, response.Transfer.Memo)
+	}
+
+	unmoderated, err := ClaimTransferMemoUnmoderated(ctx, tx, now, time.Second, 1000)
+	if err != nil {
+		t.Fatalf("Failed to read unmoderated transfer memos.\n  Error: %s", err)
+	}
+	if !slices.ContainsFunc(unmoderated, func(u Transfer) bool { return u.ID == response.Transfer.ID }) {
+		t.Fatalf("Transfer memo should be unmoderated.")
+	}
+
+	moderations := []TransferModeration{{
+		TransferID: response.Transfer.ID,
+		Censored:   true,
+		Note:       "test",
+	}}
+	err = CreateTransferModeration(ctx, tx, moderations, now)
+	if err != nil {
+		t.Fatalf("Failed to create transfer moderation.\n  Error: %s", err)
+	}
+
+	unmoderated, err = ClaimTransferMemoUnmoderated(ctx, tx, now, time.Second, 1000)
+	if err != nil {
+		t.Fatalf("Failed to read unmoderated transfer memos.\n  Error: %s", err)
+	}
+	if slices.ContainsFunc(unmoderated, func(u Transfer) bool { return u.ID == response.Transfer.ID }) {
+		t.Fatalf("Transfer memo should be moderated.")
+	}
+	censored, err := ReadTransferMemoCensored(ctx, tx, []uuid.UUID{response.Transfer.ID})
+	if err != nil {
+		t.Fatalf("Failed to read censored transfer memos.\n  Error: %s", err)
+	}
+	if len(censored) != 1 || censored[0] != response.Transfer.ID {
+		t.Fatalf("Transfer memo should be censored.")
+	}
+
+	entries, _, err := ReadStatement(ctx, tx, ReadStatementRequest{Address: sender, Limit: 1000})
+	if err != nil {
+		t.Fatalf("Failed to read statement.\n  Error: %s", err)
+	}
+	i := slices.IndexFunc(entries, func(e StatementEntry) bool { return e.TransferID == response.Transfer.ID })
+	if i == -1 {
+		t.Fatalf("Transfer should be in the statement.")
+	}
+
+	// The feed reads the memo text and its censored flag separately. The statement must agree with it.
+	feed, err := GetFeed(ctx, tx, GetFeedRequest{Now: now, Address: &sender, Kind: FeedKindTransfer})
+	if err != nil {
+		t.Fatalf("Failed to read feed.\n  Error: %s", err)
+	}
+	j := slices.IndexFunc(feed.Feed.Transfer, func(f Transfer) bool { return f.ID == response.Transfer.ID })
+	if j == -1 {
+		t.Fatalf("Transfer should be in the feed.")
+	}
+	feedCensored := slices.Contains(censored, feed.Feed.Transfer[j].ID)
+	if entries[i].Memo != feed.Feed.Transfer[j].Memo || entries[i].MemoCensored != feedCensored {
+		t.Fatalf("Statement and feed disagree about the censored memo.\n  Statement: %q %t\n  Feed: %q %t", entries[i].Memo, entries[i].MemoCensored, feed.Feed.Transfer[j].Memo, feedCensored)
+	}
+	if entries[i].Memo != memo || !entries[i].MemoCensored {
+		t.Fatalf("Censored memo should keep its text and be flagged in the statement.")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/netip"
+	"sync"
 	"testing"
 	"time"
 
@@ -99,3 +100,138 @@ func TestCreateTransfer(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateTransfer_IdempotencyKey(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction.\n  Error: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	addr1 := netip.MustParseAddr("192.168.0.1")
+	addr2 := netip.MustParseAddr("192.168.0.2")
+
+	request := CreateTransferRequest{
+		Amount:         10,
+		Sender:         addr1,
+		Now:            now,
+		Recipient:      addr2,
+		IdempotencyKey: "test-key",
+	}
+	original, err := CreateTransfer(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to transfer.\n  Error: %s", err)
+	}
+
+	request.Now = now.Add(time.Minute)
+	replayed, err := CreateTransfer(ctx, tx, request)
+	if err != nil {
+		t.Fatalf("Failed to replay transfer.\n  Error: %s", err)
+	}
+	if replayed.Transfer.ID != original.Transfer.ID {
+		t.Fatalf("Replayed transfer should have the original ID.\n  Expected: %s\n  Actual: %s", original.Transfer.ID, replayed.Transfer.ID)
+	}
+	if replayed.SenderBalance != original.SenderBalance {
+		t.Fatalf("Replayed transfer should have the original sender balance.\n  Expected: %d\n  Actual: %d", original.SenderBalance, replayed.SenderBalance)
+	}
+
+	balance, err := GetBalance(ctx, tx, GetBalanceRequest{Address: addr1, Now: now})
+	if err != nil {
+		t.Fatalf("Failed to read balance.\n  Error: %s", err)
+	}
+	if balance != BalanceUntouched(now)-request.Amount {
+		t.Fatalf("Replayed transfer should not move coins twice.\n  Expected: %d\n  Actual: %d", BalanceUntouched(now)-request.Amount, balance)
+	}
+
+	request.Amount = 11
+	_, err = CreateTransfer(ctx, tx, request)
+	if !errors.Is(err, ErrIdempotencyKeyReuse) {
+		t.Fatalf("Should have failed when reusing an idempotency key for a different transfer.\n  Error: %s", err)
+	}
+}
+
+func TestCreateTransfer_Concurrent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Every transfer commits on its own connection, so use fresh documentation addresses and remove them afterward.
+	random := func() netip.Addr {
+		b := [16]byte(uuid.New())
+		b[0], b[1], b[2], b[3] = 0x20, 0x01, 0x0d, 0xb8
+		return netip.AddrFrom16(b)
+	}
+	sender := random()
+	recipient := random()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := pool.Exec(ctx, "DELETE FROM transfer WHERE sender = $1", sender)
+		if err != nil {
+			t.Errorf("Failed to delete transfers.\n  Error: %s", err)
+		}
+		_, err = pool.Exec(ctx, "DELETE FROM address_stats WHERE address = ANY ($1)", []netip.Addr{sender, recipient})
+		if err != nil {
+			t.Errorf("Failed to delete address stats.\n  Error: %s", err)
+		}
+	})
+
+	const (
+		attempts  = 20
+		successes = 5
+	)
+	transferNow := now
+	available := BalanceUntouched(transferNow)
+	amount := available / successes
+
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := pool.Begin(ctx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer tx.Rollback(ctx)
+			_, err = CreateTransfer(ctx, tx, CreateTransferRequest{
+				Amount:    amount,
+				Sender:    sender,
+				Now:       transferNow,
+				Recipient: recipient,
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			errs <- tx.Commit(ctx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrInsufficientBalance):
+			t.Fatalf("Transfer should succeed or fail with insufficient balance.\n  Error: %s", err)
+		}
+	}
+	if succeeded != successes {
+		t.Fatalf("Transfers succeeded %d times but the balance only covers %d.", succeeded, successes)
+	}
+
+	balance, err := GetBalance(ctx, pool, GetBalanceRequest{Address: sender, Now: transferNow})
+	if err != nil {
+		t.Fatalf("Failed to read balance.\n  Error: %s", err)
+	}
+	if balance < 0 || balance != available-successes*amount {
+		t.Fatalf("Balance incorrect after concurrent transfers.\n  Expected: %d\n  Actual: %d", available-successes*amount, balance)
+	}
+}